@@ -0,0 +1,38 @@
+package myflags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// addActionAliases registers the aliases specified by AliasesTag of the child action,
+// fieldName is the field name of the action
+func (filler *Filler) addActionAliases(child *Filler, fieldName string) error {
+	val, ok := child.tag.Lookup(AliasesTag)
+	if !ok {
+		return nil
+	}
+	for _, alias := range strings.Split(val, ",") {
+		alias = strings.TrimSpace(alias)
+		if alias == "" {
+			continue
+		}
+		if filler.isReservedActionName(alias) {
+			return fmt.Errorf("alias %v of action %v collides with the built-in action %v", alias, child.fs.Name(), alias)
+		}
+		if existing, ok := filler.fsMap[alias]; ok {
+			return fmt.Errorf("alias %v of action %v collides with action %v", alias, child.fs.Name(), existing.fs.Name())
+		}
+		filler.fsMap[alias] = child
+		filler.translatedActNameMap[alias] = fieldName
+		child.aliases = append(child.aliases, alias)
+	}
+	return nil
+}
+
+// isReservedActionName returns true if name is reserved for a built-in action of filler and can't be used
+// as an action name or alias, i.e. the completion action of the root filler;
+// the built-in help action is not reserved, an action or alias named "help" replaces it
+func (filler *Filler) isReservedActionName(name string) bool {
+	return filler.parent == nil && name == CompleteActionName
+}
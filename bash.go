@@ -0,0 +1,129 @@
+package myflags
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// bashTemplate is the body of the bash completion script, %[1]v is the function name prefix,
+// %[2]v is the level cases, %[3]v is the name of the built-in help action or empty if there is none,
+// %[4]v is the command name, %[5]v is CompleteActionName
+const bashTemplate = `_%[1]v_level() {
+    case "$1" in
+%[2]v    *)
+        return 1
+        ;;
+    esac
+}
+
+_%[1]v() {
+    local line="${COMP_LINE:0:COMP_POINT}"
+    local -a words
+    read -r -a words <<< "$line"
+    local cur=""
+    if [[ $line != *[[:space:]] && ${#words[@]} -gt 1 ]]; then
+        cur="${words[${#words[@]}-1]}"
+        unset 'words[${#words[@]}-1]'
+    fi
+    local helpaction=%[3]v path="" flags="" values="" actions="" aliases="" inarg=0 help=0 word name alias
+    _%[1]v_level "$path" || return 0
+    for word in "${words[@]:1}"; do
+        if [[ $inarg -eq 1 ]]; then
+            [[ $word != -* ]] && inarg=0
+            continue
+        fi
+        if [[ $word == -* ]]; then
+            [[ $word == *=* ]] && continue
+            name="${word#-}"
+            name="${name#-}"
+            [[ " $values " == *" $name "* ]] && inarg=1
+            continue
+        fi
+        if [[ -n $helpaction && -z $path && $help -eq 0 && $word == "$helpaction" ]]; then
+            help=1
+            continue
+        fi
+        for alias in $aliases; do
+            [[ $word == "${alias%%%%=*}" ]] && word="${alias#*=}"
+        done
+        [[ " $actions " == *" $word "* ]] || return 0
+        path="${path:+$path }$word"
+        _%[1]v_level "$path" || return 0
+    done
+    if [[ $inarg -eq 1 || $cur == -*=* ]]; then
+        local candidate
+        while IFS= read -r candidate; do
+            if [[ $cur == -*=* ]]; then
+                candidate="${candidate#*=}"
+                [[ ${COMP_WORDS[COMP_CWORD]} == "=" ]] && candidate="=$candidate"
+            fi
+            COMPREPLY+=("$candidate")
+        done < <("${words[0]}" %[5]v "${words[@]:1}" "$cur" 2>/dev/null)
+        [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == */ ]] && compopt -o nospace 2>/dev/null
+        return 0
+    fi
+    if [[ $help -eq 1 ]]; then
+        COMPREPLY=($(compgen -W "$actions" -- "$cur"))
+        return 0
+    fi
+    if [[ -z $path && -n $helpaction ]]; then
+        actions="${actions:+$actions }$helpaction"
+    fi
+    case "$cur" in
+    --*)
+        COMPREPLY=($(compgen -P "--" -W "$flags" -- "${cur#--}"))
+        ;;
+    -*)
+        COMPREPLY=($(compgen -P "-" -W "$flags" -- "${cur#-}"))
+        ;;
+    *)
+        if [[ -n $actions ]]; then
+            COMPREPLY=($(compgen -W "$actions" -- "$cur"))
+        else
+            COMPREPLY=($(compgen -P "-" -W "$flags" -- "$cur"))
+        fi
+        ;;
+    esac
+}
+
+complete -F _%[1]v %[4]v
+`
+
+// GenBashCompletion writes a bash completion script of the filler to w, which completes action names
+// and flags valid at the current action level, flag values are completed by calling the command with
+// CompleteActionName; hidden and deprecated flags and hidden actions are not completed.
+// The script could be sourced in bash, e.g. "source <(cptool completion)".
+func (filler *Filler) GenBashCompletion(w io.Writer) error {
+	name := filler.fs.Name()
+	funcName := completionFuncName(name)
+	cases := new(strings.Builder)
+	for _, level := range filler.completionLevels() {
+		flags := []string{}
+		for _, info := range level.flags {
+			flags = append(flags, info.name)
+		}
+		fmt.Fprintf(cases, "    %v)\n", shQuote(strings.Join(level.path, " ")))
+		fmt.Fprintf(cases, "        flags=%v\n", shQuote(strings.Join(flags, " ")))
+		fmt.Fprintf(cases, "        values=%v\n", shQuote(strings.Join(level.valueFlags, " ")))
+		fmt.Fprintf(cases, "        actions=%v\n", shQuote(strings.Join(level.actions, " ")))
+		aliases := []string{}
+		for _, act := range level.actions {
+			for _, alias := range level.actionAliases(act) {
+				aliases = append(aliases, alias+"="+act)
+			}
+		}
+		fmt.Fprintf(cases, "        aliases=%v\n", shQuote(strings.Join(aliases, " ")))
+		fmt.Fprintln(cases, "        ;;")
+	}
+	helpAction := ""
+	if filler.hasHelpAction() {
+		helpAction = HelpActionName
+	}
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "# bash completion for %v, generated by myflags\n\n", name)
+	fmt.Fprintf(buf, bashTemplate, funcName, cases.String(), shQuote(helpAction), shQuote(name), CompleteActionName)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,448 @@
+package myflags
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// CompleteActionName is the name of the hidden action used by generated completion scripts,
+// the args following it are the words of the command line, the last one is the partial word being completed,
+// e.g. "cptool __complete compress -profile pr" prints candidates of the value of -profile starting with "pr",
+// one per line; it could only be used as the first arg
+const CompleteActionName = "__complete"
+
+// CompleteTag is the struct field tag used to specify the completion candidates of the field value,
+// separated by ",", e.g. `complete:"fast,slow"`; for a list field, they are the candidates of each element.
+// The value is not validated against the candidates
+const CompleteTag = "complete"
+
+// ErrCompletion is returned by ParseArgs after the completion candidates are printed,
+// under flag.ExitOnError, ParseArgs exits with 0 instead
+var ErrCompletion = errors.New("completion requested")
+
+// Completer could be implemented by a field type to provide completion candidates of its value,
+// toComplete is the partial value being completed; for a list field, it is implemented by the element type
+type Completer interface {
+	Complete(toComplete string) []string
+}
+
+// CompletionFunc returns completion candidates of a flag value, toComplete is the partial value being completed
+type CompletionFunc func(toComplete string) []string
+
+// fieldCompletion is a CompletionFunc registered for a field
+type fieldCompletion struct {
+	field reflect.Value //pointer to the field
+	f     CompletionFunc
+}
+
+// WithCompletionFunc returns a FillerOption that specifies f to complete the value of the flag created for
+// the field, which is a pointer to a field of the struct to be filled, e.g. &conf.Compress.Profile;
+// it takes precedence over Completer and tags
+func WithCompletionFunc(field any, f CompletionFunc) FillerOption {
+	return func(filler *Filler) {
+		filler.completionFuncs = append(filler.completionFuncs, fieldCompletion{field: reflect.ValueOf(field), f: f})
+	}
+}
+
+// WithCompletionWriter returns a FillerOption that specifies the writer of completion candidates,
+// default is os.Stdout
+func WithCompletionWriter(w io.Writer) FillerOption {
+	return func(filler *Filler) {
+		filler.completionWriter = w
+	}
+}
+
+// completionLevel is the completion data of filler at one action level
+type completionLevel struct {
+	//path is the action names from root to filler, empty for root
+	path   []string
+	filler *Filler
+	//flags are the visible flags that are not deprecated, which are offered as completion candidates
+	flags []*flagInfo
+	//valueFlags are names of all flags that take a value, including hidden flags and old names,
+	//used to skip the value slot like getNextActPosState
+	valueFlags []string
+	//actions are the visible child action names
+	actions []string
+}
+
+// completionLevels returns completion data of filler and all its descendants in depth first order
+func (filler *Filler) completionLevels() []*completionLevel {
+	r := []*completionLevel{}
+	var walkLevel func(f *Filler, path []string)
+	walkLevel = func(f *Filler, path []string) {
+		level := &completionLevel{
+			path:    path,
+			filler:  f,
+			flags:   f.completionFlags(),
+			actions: f.visibleActions(),
+		}
+		f.fs.VisitAll(func(fl *flag.Flag) {
+			if b, ok := fl.Value.(isBoolInt); !ok || !b.IsBoolFlag() {
+				level.valueFlags = append(level.valueFlags, fl.Name)
+			}
+		})
+		r = append(r, level)
+		for _, childname := range level.actions {
+			walkLevel(f.fsMap[childname], append(append([]string{}, path...), childname))
+		}
+	}
+	walkLevel(filler, []string{})
+	return r
+}
+
+// completionFlags returns the visible flags that are not deprecated
+func (filler *Filler) completionFlags() []*flagInfo {
+	r := []*flagInfo{}
+	for _, info := range filler.visibleFlags() {
+		if !info.isDeprecated {
+			r = append(r, info)
+		}
+	}
+	return r
+}
+
+// completionFuncName returns name with characters not allowed in a shell function name replaced by "_"
+func completionFuncName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// shQuote returns s single quoted for POSIX shells
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// usageLine returns the usage of the flag in a single line
+func (info *flagInfo) usageLine() string {
+	if f := info.owner.fs.Lookup(info.name); f != nil {
+		return strings.Join(strings.Fields(f.Usage), " ")
+	}
+	return ""
+}
+
+// actionAliases returns the aliases of the child action
+func (level *completionLevel) actionAliases(action string) []string {
+	return level.filler.fsMap[action].aliases
+}
+
+// actionUsageLine returns the usage of the child action in a single line
+func (level *completionLevel) actionUsageLine(action string) string {
+	return strings.Join(strings.Fields(level.filler.fsMap[action].usage), " ")
+}
+
+// hasCompleteAction returns true if filler handles CompleteActionName
+func (filler *Filler) hasCompleteAction() bool {
+	if filler.parent != nil {
+		return false
+	}
+	_, exists := filler.fsMap[CompleteActionName]
+	return !exists
+}
+
+// lookupCompletionFunc returns the CompletionFunc registered for the field of info, nil if not found
+func (info *flagInfo) lookupCompletionFunc() CompletionFunc {
+	for _, fc := range info.owner.completionFuncs {
+		v := fc.field
+		if v.Kind() != reflect.Pointer || v.IsNil() {
+			continue
+		}
+		if v.Type() == info.ref.Type() && v.Pointer() == info.ref.Pointer() {
+			return fc.f
+		}
+		//the field is a pointer, info.ref is the field value
+		if v.Elem().Kind() == reflect.Pointer && !v.Elem().IsNil() &&
+			v.Elem().Type() == info.ref.Type() && v.Elem().Pointer() == info.ref.Pointer() {
+			return fc.f
+		}
+	}
+	return nil
+}
+
+// completer returns the Completer implemented by the field type of info, nil if not implemented
+func (info *flagInfo) completer() Completer {
+	if info.isList() {
+		t := info.ref.Type().Elem().Elem()
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		c, _ := reflect.New(t).Interface().(Completer)
+		return c
+	}
+	c, _ := info.ref.Interface().(Completer)
+	return c
+}
+
+// valueType returns the type of the value of info, it is the element type for a list field
+func (info *flagInfo) valueType() reflect.Type {
+	t := info.ref.Type().Elem()
+	if info.isList() {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// wellKnownCompleters are the completion functions of well known types that are not registered,
+// key is getTypeName of the type
+var wellKnownCompleters = map[string]func(toComplete string) []string{
+	"net/netip=>netip.Addr": func(string) []string {
+		return localAddrs(false)
+	},
+	"net/netip=>netip.Prefix": func(string) []string {
+		return localAddrs(true)
+	},
+	"net=>net.IP": func(string) []string {
+		return localAddrs(false)
+	},
+}
+
+// localAddrs returns addresses of local interfaces, or their prefixes if prefix is true
+func localAddrs(prefix bool) []string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	r := []string{}
+	seen := map[string]bool{}
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		addr, ok := netip.AddrFromSlice(ipnet.IP)
+		if !ok {
+			continue
+		}
+		s := addr.Unmap().String()
+		if prefix {
+			ones, _ := ipnet.Mask.Size()
+			s = netip.PrefixFrom(addr.Unmap(), ones).Masked().String()
+		}
+		if !seen[s] {
+			seen[s] = true
+			r = append(r, s)
+		}
+	}
+	return r
+}
+
+// completeType returns completion candidates of a value of type t, via the ValueCompleter implemented by
+// the registered converter of t, or the completion function of a well known type
+func completeType(t reflect.Type, toComplete string, tag reflect.StructTag) []string {
+	if conv := globalRegistry.GetViaType(t); conv != nil {
+		if vc, ok := conv.(ValueCompleter); ok {
+			return vc.CompleteValue(toComplete, tag)
+		}
+	}
+	if f, ok := wellKnownCompleters[getTypeName(t)]; ok {
+		return f(toComplete)
+	}
+	return nil
+}
+
+// completePath returns files and directories with prefix toComplete, a directory ends with "/";
+// only directories are returned if dirOnly is true
+func completePath(toComplete string, dirOnly bool) []string {
+	dir, base := filepath.Split(toComplete)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(expandPath(readDir))
+	if err != nil {
+		return nil
+	}
+	r := []string{}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		isDir := e.IsDir()
+		if e.Type()&os.ModeSymlink != 0 {
+			if fi, err := os.Stat(filepath.Join(expandPath(readDir), name)); err == nil {
+				isDir = fi.IsDir()
+			}
+		}
+		switch {
+		case isDir:
+			r = append(r, dir+name+"/")
+		case !dirOnly:
+			r = append(r, dir+name)
+		}
+	}
+	return r
+}
+
+// resolveCandidates parses the complete tag of info
+func (info *flagInfo) resolveCandidates() error {
+	val, ok := info.tag.Lookup(CompleteTag)
+	if !ok {
+		return nil
+	}
+	info.candidates = []string{}
+	for _, c := range strings.Split(val, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			info.candidates = append(info.candidates, c)
+		}
+	}
+	if len(info.candidates) == 0 {
+		return fmt.Errorf("flag -%v: complete tag has no candidate", info.name)
+	}
+	return nil
+}
+
+// completeValue returns completion candidates of the value of info, which have prefix toComplete,
+// from the registered CompletionFunc, Completer, complete tag, path tag or the value type, in order of precedence;
+// for a list field, only the last element is completed
+func (info *flagInfo) completeValue(toComplete string) []string {
+	prefix := ""
+	if info.isList() {
+		if i := strings.LastIndex(toComplete, ","); i >= 0 {
+			prefix, toComplete = toComplete[:i+1], toComplete[i+1:]
+		}
+	}
+	var candidates []string
+	if f := info.lookupCompletionFunc(); f != nil {
+		candidates = f(toComplete)
+	} else if c := info.completer(); c != nil {
+		candidates = c.Complete(toComplete)
+	} else if info.candidates != nil {
+		candidates = info.candidates
+	} else if info.pathRule != nil {
+		candidates = completePath(toComplete, info.pathRule.isDir)
+	} else {
+		candidates = completeType(info.valueType(), toComplete, info.tag)
+	}
+	r := []string{}
+	for _, c := range candidates {
+		if strings.HasPrefix(c, toComplete) {
+			r = append(r, prefix+c)
+		}
+	}
+	return r
+}
+
+// lookupFlagByName returns the flag of filler with name or old name, nil if not found
+func (filler *Filler) lookupFlagByName(name string) *flagInfo {
+	if info, ok := filler.flagMap[name]; ok {
+		return info
+	}
+	return filler.oldNameMap[name]
+}
+
+// complete returns completion candidates of the last word in args, other words are the preceding ones
+// of the command line without the command name; action path, flags and their values are recognized
+// like getNextActPosState
+func (filler *Filler) complete(args []string) []string {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	words, toComplete := args[:len(args)-1], args[len(args)-1]
+	level := filler
+	var valueFlag *flagInfo //the flag expecting a value
+	help := false
+	for _, word := range words {
+		if valueFlag != nil {
+			if !strings.HasPrefix(word, "-") {
+				valueFlag = nil
+			}
+			continue
+		}
+		if strings.HasPrefix(word, "-") {
+			if strings.Contains(word, "=") {
+				continue
+			}
+			if info := level.lookupFlagByName(strings.TrimPrefix(word[1:], "-")); info != nil && !info.isBool() {
+				valueFlag = info
+			}
+			continue
+		}
+		if word == HelpActionName && level == filler && !help && filler.hasHelpAction() {
+			help = true
+			continue
+		}
+		child, ok := level.fsMap[word]
+		if !ok {
+			return nil
+		}
+		level = child
+	}
+	if valueFlag != nil {
+		return valueFlag.completeValue(toComplete)
+	}
+	if strings.HasPrefix(toComplete, "-") {
+		dashes := "-"
+		if strings.HasPrefix(toComplete, "--") {
+			dashes = "--"
+		}
+		name, val, found := strings.Cut(toComplete[len(dashes):], "=")
+		if found {
+			info := level.lookupFlagByName(name)
+			if info == nil {
+				return nil
+			}
+			r := []string{}
+			for _, c := range info.completeValue(val) {
+				r = append(r, dashes+name+"="+c)
+			}
+			return r
+		}
+		if help {
+			return nil
+		}
+		return level.completeFlagNames(dashes, name)
+	}
+	actions := level.visibleActions()
+	if level == filler && !help && filler.hasHelpAction() {
+		actions = append(actions, HelpActionName)
+	}
+	if toComplete == "" && len(actions) == 0 && !help {
+		return level.completeFlagNames("-", "")
+	}
+	r := []string{}
+	for _, act := range actions {
+		if strings.HasPrefix(act, toComplete) {
+			r = append(r, act)
+		}
+	}
+	return r
+}
+
+// completeFlagNames returns names of flags with prefix, prepended with dashes
+func (filler *Filler) completeFlagNames(dashes, prefix string) []string {
+	r := []string{}
+	for _, info := range filler.completionFlags() {
+		if strings.HasPrefix(info.name, prefix) {
+			r = append(r, dashes+info.name)
+		}
+	}
+	return r
+}
+
+// runCompletion writes completion candidates of args to the completion writer, one per line
+func (filler *Filler) runCompletion(args []string) error {
+	for _, c := range filler.complete(args) {
+		fmt.Fprintln(filler.completionWriter, c)
+	}
+	if filler.errHandle == flag.ExitOnError {
+		os.Exit(0)
+	}
+	return ErrCompletion
+}
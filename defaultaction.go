@@ -0,0 +1,62 @@
+package myflags
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	//DefaultActionTag is the struct field tag used to mark an action as the default one of its level,
+	//which is selected when no action is specified at the level, e.g. `action:"" default:""`;
+	//only one action per level could be marked
+	DefaultActionTag = "default"
+	//ActionRequiredTag is the struct field tag used on an action field to specify whether one of its child actions
+	//must be specified, the value is a bool, empty means true; it overrides WithActionRequired for the action
+	ActionRequiredTag = "actionrequired"
+)
+
+var ErrActionRequired = errors.New("action required")
+
+// WithActionRequired returns a FillerOption that specifies whether one of the child actions must be specified
+// for each level that has child actions, default is false; it could be overridden per action via ActionRequiredTag
+func WithActionRequired(required bool) FillerOption {
+	return func(filler *Filler) {
+		filler.actionRequired = required
+	}
+}
+
+// setActionAttrs sets the default action of filler and whether child requires an action,
+// according to tag of the child action, fname is the name of the child action
+func (filler *Filler) setActionAttrs(child *Filler, fname string, tag reflect.StructTag) error {
+	if _, ok := tag.Lookup(DefaultActionTag); ok {
+		if filler.defaultAction != "" {
+			return fmt.Errorf("action %v and %v are both marked as default", filler.defaultAction, fname)
+		}
+		filler.defaultAction = fname
+	}
+	if val, ok := tag.Lookup(ActionRequiredTag); ok {
+		required, err := parseBoolTag(val)
+		if err != nil {
+			return fmt.Errorf("action %v: invalid %v tag %v, %w", fname, ActionRequiredTag, val, err)
+		}
+		child.actionRequired = required
+	}
+	return nil
+}
+
+// actionRequiredErr returns an error if filler requires an action but there is no default action,
+// it lists the visible child actions, or all of them if they are all hidden
+func (filler *Filler) actionRequiredErr() error {
+	if !filler.actionRequired || filler.defaultAction != "" || len(filler.orderList) == 0 {
+		return nil
+	}
+	choices := filler.visibleActions()
+	if len(choices) == 0 {
+		//all child actions are hidden
+		choices = filler.orderList
+	}
+	return fmt.Errorf("%w: %v, choose one of %v", ErrActionRequired,
+		strings.Join(filler.commandPath(), " "), strings.Join(choices, ", "))
+}
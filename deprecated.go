@@ -0,0 +1,87 @@
+package myflags
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	//DeprecatedTag is the struct field tag used to mark the flag as deprecated, the tag value is the message
+	//appended to the warning, e.g. "use -skip instead"
+	DeprecatedTag = "deprecated"
+	//OldNamesTag is the struct field tag used to specify a list of old names of the flag separated by ",",
+	//each old name is registered as a hidden flag forwarding to the same field
+	OldNamesTag = "oldnames"
+)
+
+// WithWarningWriter returns a FillerOption that specifies the writer of warnings like using a deprecated flag,
+// default is os.Stderr
+func WithWarningWriter(w io.Writer) FillerOption {
+	return func(filler *Filler) {
+		filler.warnWriter = w
+	}
+}
+
+// WithShowDeprecated returns a FillerOption that specifies whether to list deprecated flags and old names
+// in a dedicated section of UsageStr, by default they are hidden
+func WithShowDeprecated(show bool) FillerOption {
+	return func(filler *Filler) {
+		filler.showDeprecated = show
+	}
+}
+
+// setDeprecation parses the deprecated and oldnames tag of info, and registers the old names;
+// it is called after the input struct is walked, so that an old name colliding with any flag is an error
+func (filler *Filler) setDeprecation(info *flagInfo) error {
+	info.deprecatedMsg, info.isDeprecated = info.tag.Lookup(DeprecatedTag)
+	oldnames, _ := info.tag.Lookup(OldNamesTag)
+	f := filler.fs.Lookup(info.name)
+	for _, old := range strings.Split(oldnames, ",") {
+		old = strings.TrimLeft(strings.TrimSpace(old), "-")
+		if old == "" {
+			continue
+		}
+		if existing, ok := filler.oldNameMap[old]; ok {
+			return fmt.Errorf("old name %v of flag -%v collides with an old name of flag -%v", old, info.name, existing.name)
+		}
+		if filler.fs.Lookup(old) != nil {
+			return fmt.Errorf("old name %v of flag -%v collides with flag -%v", old, info.name, old)
+		}
+		filler.fs.Var(f.Value, old, f.Usage)
+		info.oldNames = append(info.oldNames, old)
+		filler.oldNameMap[old] = info
+	}
+	return nil
+}
+
+// deprecationNote returns the note of deprecated flag name shown in warning and usage
+func (filler *Filler) deprecationNote(name string) string {
+	if info, ok := filler.oldNameMap[name]; ok {
+		return fmt.Sprintf("renamed to -%v", info.name)
+	}
+	if info, ok := filler.flagMap[name]; ok && info.isDeprecated {
+		if info.deprecatedMsg == "" {
+			return "deprecated"
+		}
+		return "deprecated, " + info.deprecatedMsg
+	}
+	return ""
+}
+
+// isDeprecatedFlag returns true if name is a deprecated flag or an old name
+func (filler *Filler) isDeprecatedFlag(name string) bool {
+	return filler.deprecationNote(name) != ""
+}
+
+// warnDeprecated writes a warning for each deprecated flag or old name specified in the parsed fillers
+func warnDeprecated(parsedFillers []*Filler) {
+	for _, filler := range parsedFillers {
+		filler.fs.Visit(func(f *flag.Flag) {
+			if note := filler.deprecationNote(f.Name); note != "" {
+				fmt.Fprintf(filler.warnWriter, "warning: flag -%v is %v\n", f.Name, note)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package myflags
+
+import (
+	"strings"
+)
+
+const (
+	//DescriptionTag is the struct field tag used to specify the long description of an action
+	DescriptionTag = "description"
+	//ExamplesTag is the struct field tag used to specify examples of an action, separated by ";",
+	//each example is the args following the action path
+	ExamplesTag = "examples"
+)
+
+// Example is an example of using an action
+type Example struct {
+	//Args is the args following the action path, e.g. "-f a.txt"
+	Args string
+	//Usage is the explanation of the example, optional
+	Usage string
+}
+
+// Describer could be implemented by an action struct or the root struct to provide a long description,
+// it takes precedence over the description tag
+type Describer interface {
+	Description() string
+}
+
+// Exampler could be implemented by an action struct or the root struct to provide examples,
+// it takes precedence over the examples tag
+type Exampler interface {
+	Examples() []Example
+}
+
+// UsageExample is an example used by the usage template
+type UsageExample struct {
+	//Command is the full command of the example, including the root name and the action path
+	Command string
+	//Usage is the explanation of the example
+	Usage string
+}
+
+// longDescription returns the long description of the filler
+func (filler *Filler) longDescription() string {
+	if filler.val.IsValid() {
+		if d, ok := filler.val.Interface().(Describer); ok {
+			return d.Description()
+		}
+	}
+	return filler.tag.Get(DescriptionTag)
+}
+
+// examples returns the examples of the filler
+func (filler *Filler) examples() []Example {
+	if filler.val.IsValid() {
+		if e, ok := filler.val.Interface().(Exampler); ok {
+			return e.Examples()
+		}
+	}
+	r := []Example{}
+	for _, args := range strings.Split(filler.tag.Get(ExamplesTag), ";") {
+		if args = strings.TrimSpace(args); args != "" {
+			r = append(r, Example{Args: args})
+		}
+	}
+	return r
+}
+
+// usageExamples returns the examples of the filler with full command
+func (filler *Filler) usageExamples() []UsageExample {
+	prefix := strings.Join(filler.commandPath(), " ")
+	r := []UsageExample{}
+	for _, e := range filler.examples() {
+		cmd := prefix
+		if e.Args != "" {
+			cmd += " " + e.Args
+		}
+		r = append(r, UsageExample{Command: cmd, Usage: e.Usage})
+	}
+	return r
+}
@@ -0,0 +1,155 @@
+package myflags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Runner could be implemented by the root struct or an action struct, its Run method is called by
+// Filler.Execute when the action is parsed; the ctx carries the Execution, see ExecutionFromContext
+type Runner interface {
+	Run(ctx context.Context) error
+}
+
+// RunMode specifies which Run methods are called by Filler.Execute
+type RunMode int
+
+const (
+	//RunDeepest only calls Run of the deepest parsed action, or the root struct if no action is parsed
+	RunDeepest RunMode = iota
+	//RunEachLevel calls Run of each level on the parsed action path that implements Runner,
+	//from root to the deepest action
+	RunEachLevel
+)
+
+// WithRunMode returns a FillerOption that specifies which Run methods are called by Filler.Execute,
+// default is RunDeepest
+func WithRunMode(m RunMode) FillerOption {
+	return func(filler *Filler) {
+		filler.runMode = m
+	}
+}
+
+// BeforeHook could be implemented by the root struct or an action struct, its Before method is called by
+// Filler.Execute before any Run method, from root to the deepest parsed action
+type BeforeHook interface {
+	Before(ctx context.Context) error
+}
+
+// AfterHook could be implemented by the root struct or an action struct, its After method is called by
+// Filler.Execute after Run methods, from the deepest parsed action to root, even if Run failed;
+// err is the error returned by Run or Before; it is not called for levels whose Before failed or isn't called
+type AfterHook interface {
+	After(ctx context.Context, err error) error
+}
+
+var ErrNoHandler = errors.New("no handler")
+
+// Execution is the information of a Filler.Execute call, passed to Run methods via the ctx
+type Execution struct {
+	//Actions is the parsed action path, each is the field name of the action
+	Actions []string
+	//Structs are pointers to the root struct, followed by the struct of each parsed action
+	Structs []any
+}
+
+// Root returns the pointer to the root struct
+func (e *Execution) Root() any {
+	return e.Structs[0]
+}
+
+type executionKey struct{}
+
+// ExecutionFromContext returns the Execution in ctx, which is passed to Run methods by Filler.Execute
+func ExecutionFromContext(ctx context.Context) (*Execution, bool) {
+	e, ok := ctx.Value(executionKey{}).(*Execution)
+	return e, ok
+}
+
+// StructFromContext returns the nearest struct with type T on the parsed action path of the Execution in ctx,
+// searching from the deepest action to the root struct, e.g. StructFromContext[*Config](ctx) returns the root config
+func StructFromContext[T any](ctx context.Context) (T, bool) {
+	var zero T
+	e, ok := ExecutionFromContext(ctx)
+	if !ok {
+		return zero, false
+	}
+	for i := len(e.Structs) - 1; i >= 0; i-- {
+		if r, ok := e.Structs[i].(T); ok {
+			return r, true
+		}
+	}
+	return zero, false
+}
+
+// Execute is like ExecuteArgs, use os.Args[1:] as input
+func (filler *Filler) Execute(ctx context.Context) error {
+	return filler.ExecuteArgs(ctx, os.Args[1:])
+}
+
+// ExecuteArgs parses the args like ParseArgs, then calls Run of the parsed action path according to the RunMode,
+// surrounded by Before and After hooks; errors of Run and hooks are combined via errors.Join.
+// An error wrapping ErrNoHandler is returned if the deepest parsed action doesn't implement Runner,
+// which lists its child actions
+func (filler *Filler) ExecuteArgs(ctx context.Context, args []string) error {
+	parsedActions, parsedFillers, err := filler.parseAndCheck(args)
+	if err != nil {
+		return err
+	}
+	e := &Execution{Actions: parsedActions}
+	for _, f := range parsedFillers {
+		e.Structs = append(e.Structs, f.val.Interface())
+	}
+	ctx = context.WithValue(ctx, executionKey{}, e)
+	deepest := parsedFillers[len(parsedFillers)-1]
+	if _, ok := e.Structs[len(e.Structs)-1].(Runner); !ok {
+		return deepest.noHandlerErr()
+	}
+	//entered is the number of levels whose Before succeeded
+	entered := 0
+	for _, s := range e.Structs {
+		if b, ok := s.(BeforeHook); ok {
+			if err = b.Before(ctx); err != nil {
+				break
+			}
+		}
+		entered++
+	}
+	if err == nil {
+		err = filler.run(ctx, e)
+	}
+	errs := []error{err}
+	for i := entered - 1; i >= 0; i-- {
+		if a, ok := e.Structs[i].(AfterHook); ok {
+			errs = append(errs, a.After(ctx, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// run calls Run of the structs in e according to the RunMode
+func (filler *Filler) run(ctx context.Context, e *Execution) error {
+	if filler.runMode == RunEachLevel {
+		for _, s := range e.Structs[:len(e.Structs)-1] {
+			if r, ok := s.(Runner); ok {
+				if err := r.Run(ctx); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return e.Structs[len(e.Structs)-1].(Runner).Run(ctx)
+}
+
+// noHandlerErr returns the error that filler has no handler, listing its child actions
+func (filler *Filler) noHandlerErr() error {
+	path := strings.Join(filler.commandPath(), " ")
+	actions := filler.visibleActions()
+	if len(actions) == 0 {
+		return fmt.Errorf("%w: %v", ErrNoHandler, path)
+	}
+	return fmt.Errorf("%w: %v, available actions: %v", ErrNoHandler, path, strings.Join(actions, ", "))
+}
@@ -0,0 +1,97 @@
+package myflags
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// fishQuote returns s single quoted for fish
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// fishNames returns the name and aliases of the action f separated by space
+func fishNames(f *Filler) string {
+	return strings.Join(append([]string{f.fs.Name()}, f.aliases...), " ")
+}
+
+// fishCondition returns the condition that the command line is at the action level with path,
+// actions are the child actions of the level; each element is the names of an action separated by space
+func fishCondition(path, actions []string) string {
+	conds := []string{}
+	for _, act := range path {
+		conds = append(conds, "__fish_seen_subcommand_from "+act)
+	}
+	if len(actions) > 0 {
+		conds = append(conds, "not __fish_seen_subcommand_from "+strings.Join(actions, " "))
+	}
+	if len(conds) == 0 {
+		return ""
+	}
+	return " -n " + fishQuote(strings.Join(conds, "; and "))
+}
+
+// GenFishCompletion writes a fish completion script of the filler to w, which has one "complete" command
+// per flag and action, conditioned on the action path; flags taking a value require a parameter,
+// which is completed by calling the command with CompleteActionName, bool flags don't;
+// hidden and deprecated flags and hidden actions are not completed.
+// The output could be saved as "<prog>.fish" in ~/.config/fish/completions.
+func (filler *Filler) GenFishCompletion(w io.Writer) error {
+	name := filler.fs.Name()
+	valueFunc := "__" + completionFuncName(name) + "_complete_value"
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "# fish completion for %v, generated by myflags\n\n", name)
+	// flag values are completed by the command
+	fmt.Fprintf(buf, "function %v\n    set -l tokens (commandline -opc)\n", valueFunc)
+	fmt.Fprintf(buf, "    $tokens[1] %v $tokens[2..-1] (commandline -ct) 2>/dev/null\nend\n", CompleteActionName)
+	for _, level := range filler.completionLevels() {
+		actions := []string{}
+		for _, act := range level.actions {
+			actions = append(actions, fishNames(level.filler.fsMap[act]))
+		}
+		childActions := actions
+		if len(level.path) == 0 && filler.hasHelpAction() {
+			actions = append(append([]string{}, actions...), HelpActionName)
+		}
+		if len(level.flags)+len(actions) == 0 {
+			continue
+		}
+		path := []string{}
+		for f := level.filler; f.parent != nil; f = f.parent {
+			path = append([]string{fishNames(f)}, path...)
+		}
+		cond := fishCondition(path, actions)
+		fmt.Fprintln(buf)
+		for _, info := range level.flags {
+			fmt.Fprintf(buf, "complete -c %v%v -o %v", fishQuote(name), cond, fishQuote(info.name))
+			if info.isBool() {
+				fmt.Fprint(buf, " -f")
+			} else {
+				fmt.Fprintf(buf, " -r -f -a %v", fishQuote("("+valueFunc+")"))
+			}
+			if usage := info.usageLine(); usage != "" {
+				fmt.Fprintf(buf, " -d %v", fishQuote(usage))
+			}
+			fmt.Fprintln(buf)
+		}
+		for _, act := range level.actions {
+			fmt.Fprintf(buf, "complete -c %v%v -f -a %v", fishQuote(name), cond, fishQuote(act))
+			if usage := level.actionUsageLine(act); usage != "" {
+				fmt.Fprintf(buf, " -d %v", fishQuote(usage))
+			}
+			fmt.Fprintln(buf)
+		}
+		if len(level.path) == 0 && filler.hasHelpAction() {
+			fmt.Fprintf(buf, "complete -c %v%v -f -a %v -d %v\n", fishQuote(name), cond,
+				fishQuote(HelpActionName), fishQuote("show help of an action"))
+			helpCond := fishCondition([]string{HelpActionName}, childActions)
+			for _, act := range level.actions {
+				fmt.Fprintf(buf, "complete -c %v%v -f -a %v\n", fishQuote(name), helpCond, fishQuote(act))
+			}
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,160 @@
+package myflags
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// HelpActionName is the name of the built-in help action,
+// e.g. "cptool help compress zipfile" prints the help of action "compress zipfile"
+const HelpActionName = "help"
+
+// HelpError is returned by ParseArgs when help is requested via "-h" or the built-in help action,
+// it wraps flag.ErrHelp
+type HelpError struct {
+	//Actions is the action path of the requested help, empty for the root level
+	Actions []string
+}
+
+func (he *HelpError) Error() string {
+	if len(he.Actions) == 0 {
+		return flag.ErrHelp.Error()
+	}
+	return fmt.Sprintf("%v: %v", flag.ErrHelp, strings.Join(he.Actions, " "))
+}
+
+func (he *HelpError) Unwrap() error {
+	return flag.ErrHelp
+}
+
+// WithHelpAction returns a FillerOption that specifies whether to add the built-in help action,
+// default is true; the built-in help action is not added if there is already an action named "help"
+func WithHelpAction(enabled bool) FillerOption {
+	return func(filler *Filler) {
+		filler.helpAction = enabled
+	}
+}
+
+// DefaultHelpTemplate is the default template used by Filler.HelpStr, it could be cloned and customized
+var DefaultHelpTemplate = template.Must(template.New("help").Parse(
+	`{{define "flags"}}{{range .Groups}}{{if .Name}}{{$.Indent}}{{.Name}}:
+{{end}}{{.Table}}{{end}}{{end}}Usage: {{.Command}}{{if .Flags}} [flags]{{end}}{{if .Actions}} <action>{{end}}
+{{if .Description}}{{.Description}}
+{{end}}{{if .LongDescription}}{{.LongDescription}}
+{{end}}{{template "flags" .}}{{if .Deprecated}}{{.Indent}}deprecated:
+{{range .Deprecated}}{{$.Indent}}- {{.Name}}: {{.Deprecation}}
+{{end}}{{end}}{{range .Parents}}{{if .Flags}}{{$.Indent}}flags of {{.Command}}:
+{{template "flags" .}}{{end}}{{end}}{{if .Actions}}{{.Indent}}actions:
+{{range .Actions}}{{$.Indent}}= {{.Name}}{{if .Aliases}} ({{range $i, $a := .Aliases}}{{if $i}}, {{end}}{{$a}}{{end}}){{end}}{{if .IsDefault}} [default]{{end}}: {{.Description}}
+{{end}}{{end}}{{if .Examples}}{{.Indent}}examples:
+{{range .Examples}}{{$.Indent}}  {{.Command}}
+{{if .Usage}}{{$.Indent}}  	{{.Usage}}
+{{end}}{{end}}{{end}}`))
+
+// WithHelpTemplate returns a FillerOption that specifies the template used by Filler.HelpStr,
+// the template is executed with a *UsageData of the filler, which has Command and Parents populated,
+// and each of its Actions only has Name and Description; default is DefaultHelpTemplate
+func WithHelpTemplate(t *template.Template) FillerOption {
+	return func(filler *Filler) {
+		filler.helpTemplate = t
+	}
+}
+
+// hasHelpAction returns true if filler has the built-in help action,
+// an action or alias named "help" replaces the built-in one
+func (filler *Filler) hasHelpAction() bool {
+	if filler.parent != nil || !filler.helpAction {
+		return false
+	}
+	_, exists := filler.fsMap[HelpActionName]
+	return !exists
+}
+
+// commandPath returns the name of root filler, followed by the action names to filler
+func (filler *Filler) commandPath() []string {
+	r := []string{}
+	for f := filler; f != nil; f = f.parent {
+		r = append([]string{f.fs.Name()}, r...)
+	}
+	return r
+}
+
+// helpData returns the UsageData used by the help template
+func (filler *Filler) helpData() *UsageData {
+	r := filler.usageData("  ")
+	r.Command = strings.Join(filler.commandPath(), " ")
+	if r.LongDescription != "" {
+		r.LongDescription = wrapIndented(r.LongDescription, "", r.Width)
+	}
+	for i, child := range r.Actions {
+		r.Actions[i] = &UsageData{
+			Name:        child.Name,
+			Aliases:     child.Aliases,
+			IsDefault:   child.IsDefault,
+			Description: child.Description,
+		}
+	}
+	if filler.hasHelpAction() {
+		r.Actions = append(r.Actions, &UsageData{
+			Name:        HelpActionName,
+			Description: "show help of an action, e.g. " + HelpActionName + " <action> <sub-action>",
+		})
+	}
+	for f := filler.parent; f != nil; f = f.parent {
+		parent := f.usageData("  ")
+		parent.Command = strings.Join(f.commandPath(), " ")
+		parent.Actions = nil
+		r.Parents = append([]*UsageData{parent}, r.Parents...)
+	}
+	return r
+}
+
+// HelpStr returns the help string of the filler, which includes its flags, flags of its ancestors,
+// and its direct child actions, rendered by the help template
+func (filler *Filler) HelpStr() string {
+	buf := new(bytes.Buffer)
+	err := filler.helpTemplate.Execute(buf, filler.helpData())
+	if err != nil {
+		fmt.Fprintf(buf, "failed to render help, %v\n", err)
+	}
+	return buf.String()
+}
+
+// printHelp writes the help string to the output of filler's flagset,
+// it is used as the flagset's Usage function
+func (filler *Filler) printHelp() {
+	fmt.Fprint(filler.fs.Output(), filler.HelpStr())
+}
+
+// lookupAction returns the child filler and its field name, name could be either the action name
+// or the field name of the action
+func (filler *Filler) lookupAction(name string) (*Filler, string) {
+	if child, ok := filler.fsMap[name]; ok {
+		return child, filler.translatedActNameMap[name]
+	}
+	for actname, fieldname := range filler.translatedActNameMap {
+		if strings.EqualFold(fieldname, name) {
+			return filler.fsMap[actname], fieldname
+		}
+	}
+	return nil, ""
+}
+
+// runHelpAction prints the help of the action path specified by args
+func (filler *Filler) runHelpAction(args []string) error {
+	target := filler
+	actions := []string{}
+	for _, arg := range args {
+		child, fieldname := target.lookupAction(arg)
+		if child == nil {
+			return fmt.Errorf("%w: %v", ErrInvalidAction, strings.Join(append(target.commandPath()[1:], arg), " "))
+		}
+		target = child
+		actions = append(actions, fieldname)
+	}
+	fmt.Fprint(filler.fs.Output(), target.HelpStr())
+	return &HelpError{Actions: actions}
+}
@@ -0,0 +1,51 @@
+package myflags
+
+import (
+	"os"
+	"strconv"
+)
+
+// ShowHiddenEnv is the environment variable that reveals hidden flags and actions when it is set to true, e.g. "1"
+const ShowHiddenEnv = "MYFLAGS_SHOW_HIDDEN"
+
+// WithShowHidden returns a FillerOption that specifies whether to show hidden flags and actions,
+// default is false, unless the environment variable MYFLAGS_SHOW_HIDDEN is set to true
+func WithShowHidden(show bool) FillerOption {
+	return func(filler *Filler) {
+		filler.showHidden = show
+	}
+}
+
+// isShowingHidden returns true if hidden flags and actions should be shown
+func (filler *Filler) isShowingHidden() bool {
+	if filler.showHidden {
+		return true
+	}
+	show, _ := strconv.ParseBool(os.Getenv(ShowHiddenEnv))
+	return show
+}
+
+// visibleFlags returns flags of filler in order, not including hidden flags unless they should be shown
+func (filler *Filler) visibleFlags() []*flagInfo {
+	show := filler.isShowingHidden()
+	r := []*flagInfo{}
+	for _, info := range filler.orderedFlags() {
+		if !info.hidden || show {
+			r = append(r, info)
+		}
+	}
+	return r
+}
+
+// visibleActions returns names of child actions in declaration order, not including hidden actions
+// unless they should be shown
+func (filler *Filler) visibleActions() []string {
+	show := filler.isShowingHidden()
+	r := []string{}
+	for _, childname := range filler.orderList {
+		if !filler.fsMap[childname].hidden || show {
+			r = append(r, childname)
+		}
+	}
+	return r
+}
@@ -1,6 +1,7 @@
 package myflags
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -20,6 +21,82 @@ func init() {
 	Register[uint64](&intType{len: 64, isUint: true})
 }
 
+const (
+	//StrictTag is the struct field tag used to enable or disable strict conversion for a number field,
+	//overrides StrictNumberConversion; an empty value means enabled
+	StrictTag = "strict"
+	//DigitSepTag is the struct field tag used to specify digit separator characters of a number field,
+	//which are removed before conversion, an empty value means "_"
+	DigitSepTag = "digitsep"
+)
+
+// StrictNumberConversion specifies whether to use strict conversion for int/uint/float types,
+// can be overridden per field by field tag "strict". In strict mode:
+//   - error of parsing a float is returned
+//   - the base tag also specifies the base of input, the prefix of the base like "0x" is optional
+//   - the out of range error includes the value range of the target type
+//
+// Default is false, which means the input is parsed with base prefix like "0x" and float parsing error is ignored
+var StrictNumberConversion = false
+
+// resolveStrict checks the strict tag of info, and the base tag if strict conversion is enabled
+func (info *flagInfo) resolveStrict() error {
+	if v, ok := info.tag.Lookup(StrictTag); ok {
+		if _, err := parseBoolTag(v); err != nil {
+			return fmt.Errorf("flag -%v: invalid %v tag %v, %w", info.name, StrictTag, v, err)
+		}
+	}
+	if base, ok := info.tag.Lookup("base"); ok && isStrictConversion(info.tag) {
+		if _, _, err := parseBase(base); err != nil {
+			return fmt.Errorf("flag -%v: %w", info.name, err)
+		}
+	}
+	return nil
+}
+
+// parseBase returns the base and its prefix specified by the base tag value in strict mode
+func parseBase(base string) (int, string, error) {
+	switch strings.TrimSpace(base) {
+	case "":
+		return 0, "", nil
+	case "10":
+		return 10, "", nil
+	case "2":
+		return 2, "0b", nil
+	case "8":
+		return 8, "0o", nil
+	case "16":
+		return 16, "0x", nil
+	}
+	return 0, "", fmt.Errorf("unsupported base %v", base)
+}
+
+func isStrictConversion(tag reflect.StructTag) bool {
+	if v, ok := tag.Lookup(StrictTag); ok {
+		if b, err := parseBoolTag(v); err == nil {
+			return b
+		}
+	}
+	return StrictNumberConversion
+}
+
+// removeDigitSep removes the digit separators specified by tag "digitsep" from s
+func removeDigitSep(s string, tag reflect.StructTag) string {
+	seps, ok := tag.Lookup(DigitSepTag)
+	if !ok {
+		return s
+	}
+	if seps == "" {
+		seps = "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(seps, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
 type intType struct {
 	len    int
 	isUint bool
@@ -32,34 +109,84 @@ func (i *intType) ToStr(in any, tag reflect.StructTag) string {
 	case "2":
 		fmtstr = "0b%b"
 	case "8":
-		fmtstr = "0o%O"
+		fmtstr = "%O" //%O includes the 0o prefix
 	case "16":
 		fmtstr = "0x%x"
 	}
 	return fmt.Sprintf(fmtstr, in)
 }
 
+// typeName returns the name of the int type
+func (i *intType) typeName() string {
+	r := "int"
+	if i.isUint {
+		r = "uint"
+	}
+	if i.len != 0 {
+		r += strconv.Itoa(i.len)
+	}
+	return r
+}
+
+// rangeStr returns the value range of the int type
+func (i *intType) rangeStr() string {
+	bits := i.len
+	if bits == 0 {
+		bits = strconv.IntSize
+	}
+	if i.isUint {
+		return fmt.Sprintf("[0, %d]", ^uint64(0)>>(64-bits))
+	}
+	max := int64(^uint64(0) >> (65 - bits))
+	return fmt.Sprintf("[%d, %d]", -max-1, max)
+}
+
+// parseStrict parses input in strict mode, the base tag specifies the base of input,
+// the prefix of the base like "0x" is optional
+func (i *intType) parseStrict(input string, tag reflect.StructTag) (int64, uint64, error) {
+	s := removeDigitSep(input, tag)
+	base, _ := tag.Lookup("base")
+	baseN, prefix, err := parseBase(base)
+	if err != nil {
+		return 0, 0, err
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+	if prefix != "" && len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+		s = s[len(prefix):]
+	}
+	s = sign + s
+	var n int64
+	var un uint64
+	if !i.isUint {
+		n, err = strconv.ParseInt(s, baseN, i.len)
+	} else {
+		un, err = strconv.ParseUint(s, baseN, i.len)
+	}
+	if errors.Is(err, strconv.ErrRange) {
+		return 0, 0, fmt.Errorf("%v is out of range of %v %v, %w", input, i.typeName(), i.rangeStr(), strconv.ErrRange)
+	}
+	return n, un, err
+}
+
 func (i *intType) FromStr(s string, tag reflect.StructTag) (any, error) {
-	// base, foundTag := tag.Lookup("base")
-	// // baseN := 10
-	// // switch strings.TrimSpace(base) {
-	// // case "10":
-	// // case "2":
-	// // 	baseN = 2
-	// // case "8":
-	// // 	baseN = 8
-	// // case "16":
-	// // 	baseN = 16
-	// // default:
-	// // 	if foundTag {
-	// // 		return nil, fmt.Errorf("unsupported base %v", base)
-	// // 	}
-	// // }
+	s = strings.TrimSpace(s)
+	var n int64
+	var un uint64
+	var err error
+	if isStrictConversion(tag) {
+		n, un, err = i.parseStrict(s, tag)
+	} else if !i.isUint {
+		n, err = strconv.ParseInt(removeDigitSep(s, tag), 0, i.len)
+	} else {
+		un, err = strconv.ParseUint(removeDigitSep(s, tag), 0, i.len)
+	}
+	if err != nil {
+		return nil, err
+	}
 	if !i.isUint {
-		n, err := strconv.ParseInt(strings.TrimSpace(s), 0, i.len)
-		if err != nil {
-			return nil, err
-		}
 		switch i.len {
 		case 0:
 			return int(n), nil
@@ -74,23 +201,33 @@ func (i *intType) FromStr(s string, tag reflect.StructTag) (any, error) {
 		}
 
 	} else {
-		n, err := strconv.ParseUint(strings.TrimSpace(s), 0, i.len)
-		if err != nil {
-			return nil, err
-		}
 		switch i.len {
 		case 0:
-			return uint(n), nil
+			return uint(un), nil
 		case 8:
-			return uint8(n), nil
+			return uint8(un), nil
 		case 16:
-			return uint16(n), nil
+			return uint16(un), nil
 		case 32:
-			return uint32(n), nil
+			return uint32(un), nil
 		case 64:
-			return uint64(n), nil
+			return uint64(un), nil
 		}
 
 	}
 	return nil, fmt.Errorf("not a supported type")
 }
+
+// Format implements FormatDescriber interface
+func (i *intType) Format(tag reflect.StructTag) string {
+	base, _ := tag.Lookup("base")
+	switch strings.TrimSpace(base) {
+	case "2":
+		return i.typeName() + ", binary"
+	case "8":
+		return i.typeName() + ", octal"
+	case "16":
+		return i.typeName() + ", hex"
+	}
+	return i.typeName()
+}
@@ -0,0 +1,238 @@
+package myflags
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ManMeta is the metadata of generated man pages
+type ManMeta struct {
+	//Title is the title of the page, default is the upper case of the command path joined by "-"
+	Title string
+	//Date is the date shown in the page footer, it is not generated, so that the output is deterministic
+	Date string
+	//Source is the source of the command, e.g. "cptool 1.0"
+	Source string
+	//Manual is the title of the manual, e.g. "User Commands"
+	Manual string
+	//Combined specifies whether to include all descendant actions in a single page,
+	//otherwise only direct child actions are listed in COMMANDS section
+	Combined bool
+}
+
+// roffEscape escapes s for roff text
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			lines[i] = `\&` + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// roffQuote returns s as a quoted roff macro argument
+func roffQuote(s string) string {
+	return `"` + strings.ReplaceAll(roffEscape(s), `"`, `\(dq`) + `"`
+}
+
+// roffParagraphs writes text as roff paragraphs, explicit newlines are preserved as line breaks
+func roffParagraphs(w io.Writer, text string) {
+	fmt.Fprintln(w, strings.ReplaceAll(roffEscape(text), "\n", "\n.br\n"))
+}
+
+// writeManOptions writes flags of data as a roff tagged paragraph list, grouped by sections,
+// the section name is written as a subsection heading if subsection is true, otherwise in bold
+func writeManOptions(w io.Writer, data *UsageData, subsection bool) {
+	for _, g := range data.Groups {
+		if g.Name != "" {
+			if subsection {
+				fmt.Fprintf(w, ".SS %v\n", roffEscape(g.Name))
+			} else {
+				fmt.Fprintf(w, ".PP\n\\fB%v\\fR\n", roffEscape(g.Name))
+			}
+		}
+		for _, f := range g.Flags {
+			fmt.Fprintln(w, ".TP")
+			if f.Placeholder != "" {
+				fmt.Fprintf(w, `.B \-%v \fI%v\fR`+"\n", roffEscape(f.Name), roffEscape(f.Placeholder))
+			} else {
+				fmt.Fprintf(w, ".B \\-%v\n", roffEscape(f.Name))
+			}
+			if f.Usage != "" {
+				roffParagraphs(w, f.Usage)
+			}
+			if f.Default != "" {
+				if f.Usage != "" {
+					fmt.Fprintln(w, ".br")
+				}
+				fmt.Fprintf(w, "Default: %v\n", roffEscape(f.Default))
+			}
+		}
+	}
+}
+
+// writeManExamples writes examples of data
+func writeManExamples(w io.Writer, data *UsageData) {
+	for _, e := range data.Examples {
+		fmt.Fprintln(w, ".PP")
+		if e.Usage != "" {
+			roffParagraphs(w, e.Usage)
+		}
+		fmt.Fprintln(w, ".nf")
+		fmt.Fprintf(w, ".RS\n%v\n.RE\n", roffEscape(e.Command))
+		fmt.Fprintln(w, ".fi")
+	}
+}
+
+// writeManCommands writes child actions of data as a roff tagged paragraph list,
+// including options and descendants of each action if combined is true
+func writeManCommands(w io.Writer, data *UsageData, combined bool) {
+	for _, act := range data.Actions {
+		if combined {
+			fmt.Fprintf(w, ".SS %v\n", roffEscape(act.Command))
+			if act.Description != "" {
+				roffParagraphs(w, act.Description)
+			}
+			if act.LongDescription != "" {
+				fmt.Fprintln(w, ".PP")
+				roffParagraphs(w, act.LongDescription)
+			}
+			writeManOptions(w, act, false)
+			writeManExamples(w, act)
+			writeManCommands(w, act, true)
+			continue
+		}
+		fmt.Fprintln(w, ".TP")
+		fmt.Fprintf(w, ".B %v\n", roffEscape(act.Name))
+		if act.Description != "" {
+			roffParagraphs(w, act.Description)
+		}
+	}
+}
+
+// manData returns the UsageData of filler and its descendants, with Command populated for each level
+func (filler *Filler) manData() *UsageData {
+	r := filler.usageData("")
+	var setCommand func(data *UsageData, prefix string)
+	setCommand = func(data *UsageData, prefix string) {
+		data.Command = prefix
+		for _, act := range data.Actions {
+			setCommand(act, prefix+" "+act.Name)
+		}
+	}
+	setCommand(r, strings.Join(filler.commandPath(), " "))
+	return r
+}
+
+// WriteManPage writes a roff man page of the filler to w, section is the manual section like 1.
+// the page includes NAME, SYNOPSIS, DESCRIPTION, OPTIONS, COMMANDS and EXAMPLES sections,
+// with meta.Combined, it includes all descendant actions, otherwise only direct child actions.
+// The output is deterministic.
+func (filler *Filler) WriteManPage(w io.Writer, section int, meta ManMeta) error {
+	data := filler.manData()
+	path := filler.commandPath()
+	pageName := strings.Join(path, "-")
+	title := meta.Title
+	if title == "" {
+		title = strings.ToUpper(pageName)
+	}
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, ".TH %v %v %v %v %v\n", roffQuote(title), roffQuote(fmt.Sprint(section)),
+		roffQuote(meta.Date), roffQuote(meta.Source), roffQuote(meta.Manual))
+	fmt.Fprintln(buf, ".SH NAME")
+	if data.Description != "" {
+		fmt.Fprintf(buf, "%v \\- %v\n", roffEscape(pageName), roffEscape(strings.ReplaceAll(data.Description, "\n", " ")))
+	} else {
+		fmt.Fprintln(buf, roffEscape(pageName))
+	}
+	fmt.Fprintln(buf, ".SH SYNOPSIS")
+	fmt.Fprintf(buf, ".B %v\n", roffEscape(data.Command))
+	if len(data.Flags) > 0 {
+		fmt.Fprintln(buf, `[\fIflags\fR]`)
+	}
+	if len(data.Actions) > 0 {
+		fmt.Fprintln(buf, `\fIaction\fR ...`)
+	}
+	if data.Description != "" || data.LongDescription != "" {
+		fmt.Fprintln(buf, ".SH DESCRIPTION")
+		if data.Description != "" {
+			roffParagraphs(buf, data.Description)
+		}
+		if data.LongDescription != "" {
+			if data.Description != "" {
+				fmt.Fprintln(buf, ".PP")
+			}
+			roffParagraphs(buf, data.LongDescription)
+		}
+	}
+	if len(data.Flags) > 0 {
+		fmt.Fprintln(buf, ".SH OPTIONS")
+		writeManOptions(buf, data, true)
+	}
+	for f := filler.parent; f != nil; f = f.parent {
+		parent := f.manData()
+		if len(parent.Flags) > 0 {
+			fmt.Fprintf(buf, ".SH OPTIONS OF %v\n", roffEscape(strings.ToUpper(parent.Command)))
+			writeManOptions(buf, parent, true)
+		}
+	}
+	if len(data.Actions) > 0 {
+		fmt.Fprintln(buf, ".SH COMMANDS")
+		writeManCommands(buf, data, meta.Combined)
+	}
+	if len(data.Examples) > 0 {
+		fmt.Fprintln(buf, ".SH EXAMPLES")
+		writeManExamples(buf, data)
+	}
+	if !meta.Combined {
+		seeAlso := []string{}
+		if filler.parent != nil {
+			seeAlso = append(seeAlso, strings.Join(filler.parent.commandPath(), "-"))
+		}
+		for _, act := range data.Actions {
+			seeAlso = append(seeAlso, pageName+"-"+act.Name)
+		}
+		if len(seeAlso) > 0 {
+			fmt.Fprintln(buf, ".SH SEE ALSO")
+			for i, name := range seeAlso {
+				sep := ","
+				if i == len(seeAlso)-1 {
+					sep = ""
+				}
+				fmt.Fprintf(buf, "\\fB%v\\fR(%d)%v\n", roffEscape(name), section, sep)
+			}
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteManPages writes one man page for the filler and each of its descendant actions into dir,
+// the file name is the command path joined by "-" with section as the extension, e.g. "cptool-compress.1"
+func (filler *Filler) WriteManPages(dir string, section int, meta ManMeta) error {
+	meta.Combined = false
+	f, err := os.Create(filepath.Join(dir, fmt.Sprintf("%v.%d", strings.Join(filler.commandPath(), "-"), section)))
+	if err != nil {
+		return err
+	}
+	err = filler.WriteManPage(f, section, meta)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	for _, childname := range filler.visibleActions() {
+		if err = filler.fsMap[childname].WriteManPages(dir, section, meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package myflags
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// MarkdownIndexFile is the name of the index page written by Filler.WriteMarkdownFiles
+const MarkdownIndexFile = "index.md"
+
+// mdEscape escapes s for a markdown table cell
+func mdEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// mdAnchor returns the anchor of a markdown heading, following GitHub's rule
+func mdAnchor(heading string) string {
+	return "#" + strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, heading)
+}
+
+// mdFileName returns the markdown file name of the action with the command path
+func mdFileName(command string) string {
+	return strings.ReplaceAll(command, " ", "_") + ".md"
+}
+
+// writeMarkdownFlags writes flags of data as markdown tables, one table per help section,
+// level is the heading level of sections
+func writeMarkdownFlags(w io.Writer, data *UsageData, level int) {
+	for _, g := range data.Groups {
+		if g.Name != "" {
+			fmt.Fprintf(w, "%v %v\n\n", strings.Repeat("#", level), g.Name)
+		}
+		fmt.Fprintln(w, "| Name | Type | Default | Description |")
+		fmt.Fprintln(w, "|------|------|---------|-------------|")
+		for _, f := range g.Flags {
+			def := ""
+			if f.Default != "" {
+				def = "`" + mdEscape(f.Default) + "`"
+			}
+			fmt.Fprintf(w, "| `-%v` | `%v` | %v | %v |\n", f.Name, mdEscape(f.Type), def, mdEscape(f.Usage))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// writeMarkdownAction writes the document of the action data, level is the heading level of the action,
+// link returns the link of an action with the command path
+func (filler *Filler) writeMarkdownAction(w io.Writer, data *UsageData, level int, link func(command string) string) {
+	heading := strings.Repeat("#", level)
+	fmt.Fprintf(w, "%v %v\n\n", heading, data.Command)
+	if data.Description != "" {
+		fmt.Fprintf(w, "%v\n\n", data.Description)
+	}
+	if data.LongDescription != "" {
+		fmt.Fprintf(w, "%v\n\n", data.LongDescription)
+	}
+	fmt.Fprintf(w, "%v# Synopsis\n\n```\n%v", heading, data.Command)
+	if len(data.Flags) > 0 {
+		fmt.Fprint(w, " [flags]")
+	}
+	if len(data.Actions) > 0 {
+		fmt.Fprint(w, " <action>")
+	}
+	fmt.Fprint(w, "\n```\n\n")
+	if len(data.Flags) > 0 {
+		fmt.Fprintf(w, "%v# Flags\n\n", heading)
+		writeMarkdownFlags(w, data, level+2)
+	}
+	for f := filler.parent; f != nil; f = f.parent {
+		parent := f.manData()
+		if len(parent.Flags) > 0 {
+			fmt.Fprintf(w, "%v# Flags of [%v](%v)\n\n", heading, parent.Command, link(parent.Command))
+			writeMarkdownFlags(w, parent, level+2)
+		}
+	}
+	if len(data.Actions) > 0 {
+		fmt.Fprintf(w, "%v# Actions\n\n", heading)
+		fmt.Fprintln(w, "| Action | Description |")
+		fmt.Fprintln(w, "|--------|-------------|")
+		for _, act := range data.Actions {
+			fmt.Fprintf(w, "| [%v](%v) | %v |\n", act.Name, link(act.Command), mdEscape(act.Description))
+		}
+		fmt.Fprintln(w)
+	}
+	if len(data.Examples) > 0 {
+		fmt.Fprintf(w, "%v# Examples\n\n", heading)
+		for _, e := range data.Examples {
+			if e.Usage != "" {
+				fmt.Fprintf(w, "%v\n\n", e.Usage)
+			}
+			fmt.Fprintf(w, "```\n%v\n```\n\n", e.Command)
+		}
+	}
+}
+
+// writeMarkdownIndex writes a nested list of data and its descendants with links and descriptions
+func writeMarkdownIndex(w io.Writer, data *UsageData, indent string, link func(command string) string) {
+	fmt.Fprintf(w, "%v- [%v](%v)", indent, data.Command, link(data.Command))
+	if data.Description != "" {
+		fmt.Fprintf(w, ": %v", strings.ReplaceAll(data.Description, "\n", " "))
+	}
+	fmt.Fprintln(w)
+	for _, act := range data.Actions {
+		writeMarkdownIndex(w, act, indent+"  ", link)
+	}
+}
+
+// WriteMarkdown writes a markdown reference document of the filler and its descendant actions to w,
+// it starts with an index, followed by one section per action, which includes synopsis, flag tables,
+// child action links and examples
+func (filler *Filler) WriteMarkdown(w io.Writer) error {
+	data := filler.manData()
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "# %v reference\n\n", data.Command)
+	writeMarkdownIndex(buf, data, "", mdAnchor)
+	fmt.Fprintln(buf)
+	var walkAction func(f *Filler, d *UsageData)
+	walkAction = func(f *Filler, d *UsageData) {
+		f.writeMarkdownAction(buf, d, 2, mdAnchor)
+		for i, childname := range f.visibleActions() {
+			walkAction(f.fsMap[childname], d.Actions[i])
+		}
+	}
+	walkAction(filler, data)
+	_, err := w.Write(bytes.TrimRight(buf.Bytes(), "\n"))
+	if err == nil {
+		_, err = fmt.Fprintln(w)
+	}
+	return err
+}
+
+// WriteMarkdownFiles writes one markdown file per action into dir, the file name is the command path
+// joined by "_", e.g. "cptool_compress.md", and an index page MarkdownIndexFile;
+// dir is created if it doesn't exist.
+// It could be called from a program invoked by go generate.
+func (filler *Filler) WriteMarkdownFiles(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data := filler.manData()
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "# %v reference\n\n", data.Command)
+	writeMarkdownIndex(buf, data, "", mdFileName)
+	if err := os.WriteFile(filepath.Join(dir, MarkdownIndexFile), buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	var walkAction func(f *Filler, d *UsageData) error
+	walkAction = func(f *Filler, d *UsageData) error {
+		buf := new(bytes.Buffer)
+		f.writeMarkdownAction(buf, d, 1, mdFileName)
+		err := os.WriteFile(filepath.Join(dir, mdFileName(d.Command)), append(bytes.TrimRight(buf.Bytes(), "\n"), '\n'), 0644)
+		if err != nil {
+			return err
+		}
+		for i, childname := range f.visibleActions() {
+			if err = walkAction(f.fsMap[childname], d.Actions[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walkAction(filler, data)
+}
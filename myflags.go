@@ -6,9 +6,11 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"strings"
+	"text/template"
 )
 
 // encodingTextMarshaler is the interface includes both encoding.TextMarshaler and encoding.TextUnmarshaler
@@ -46,7 +48,66 @@ type Filler struct {
 	optList              []FillerOption
 	usage                string //this is the usage string for for overall filler
 	renamer              RenameFunc
-	translatedActNameMap map[string]string //key is the transalted action name, val is the original field name
+	translatedActNameMap map[string]string    //key is the transalted action name, val is the original field name
+	parent               *Filler              //parent filler, nil for the root filler
+	flags                []*flagInfo          //flags of this filler, in declaration order, not including old names
+	flagMap              map[string]*flagInfo //key is the flag name
+	validators           []reflect.Value      //pointers to structs implement Validator, in validating order
+	validateOrder        ValidateOrder
+	expandPath           bool
+	oldNameMap           map[string]*flagInfo //key is the old name of a flag
+	warnWriter           io.Writer
+	showDeprecated       bool
+	usageTemplate        *template.Template
+	helpTemplate         *template.Template
+	helpAction           bool
+	flagOrder            FlagOrder
+	hidden               bool              //true if the action is hidden
+	tag                  reflect.StructTag //struct field tag of the action, empty for the root filler
+	val                  reflect.Value     //pointer to the struct of the action or the root struct
+	showHidden           bool
+	usageWidth           int
+	completionWriter     io.Writer
+	completionFuncs      []fieldCompletion
+	runMode              RunMode
+	aliases              []string //alternative names of the action
+	defaultAction        string   //name of the default child action, "" if there is none
+	actionRequired       bool
+}
+
+// flagInfo holds the metadata of a flag created from a struct field
+type flagInfo struct {
+	name      string
+	group     string //help section of the flag, "" means the default section
+	hidden    bool
+	owner     *Filler
+	tag       reflect.StructTag
+	ref       reflect.Value //pointer to the field
+	requires  []*flagInfo
+	conflicts []*flagInfo
+	pathRule  *pathRule //nil if there is no path tag
+	//isDeprecated is true if there is deprecated tag, deprecatedMsg is the tag value
+	isDeprecated  bool
+	deprecatedMsg string
+	oldNames      []string
+	candidates    []string //candidates of complete tag, nil if there is no complete tag
+}
+
+// isSet returns true if the flag is specified in the parsed args
+func (info *flagInfo) isSet() bool {
+	found := false
+	info.owner.fs.Visit(func(f *flag.Flag) {
+		if f.Name == info.name {
+			found = true
+			return
+		}
+		for _, old := range info.oldNames {
+			if f.Name == old {
+				found = true
+			}
+		}
+	})
+	return found
 }
 
 // FillerOption is an option when creating new Filler
@@ -71,16 +132,24 @@ func WithFlagErrHandling(h flag.ErrorHandling) FillerOption {
 // optionally, a list of FillerOptions could be specified.
 func NewFiller(fsname, usage string, options ...FillerOption) *Filler {
 	r := &Filler{
-		errHandle: DefaultErrHandle,
-		renamer:   DefaultRenamer,
+		errHandle:        DefaultErrHandle,
+		renamer:          DefaultRenamer,
+		warnWriter:       os.Stderr,
+		completionWriter: os.Stdout,
+		usageTemplate:    DefaultUsageTemplate,
+		helpTemplate:     DefaultHelpTemplate,
+		helpAction:       true,
 	}
 	for _, o := range options {
 		o(r)
 	}
 	r.fsMap = make(map[string]*Filler)
 	r.translatedActNameMap = make(map[string]string)
-	r.fs = flag.NewFlagSet(fsname, r.errHandle)
-	r.fs.Usage = r.Usage
+	r.flagMap = make(map[string]*flagInfo)
+	r.oldNameMap = make(map[string]*flagInfo)
+	//errors are handled by the filler according to r.errHandle, so that help request could be returned
+	r.fs = flag.NewFlagSet(fsname, flag.ContinueOnError)
+	r.fs.Usage = r.printHelp
 	r.orderList = []string{}
 	r.usage = usage
 	r.optList = options
@@ -90,6 +159,7 @@ func NewFiller(fsname, usage string, options ...FillerOption) *Filler {
 
 func newInheritFiller(father *Filler, fsname, ousage string) *Filler {
 	r := NewFiller(fsname, ousage, father.optList...)
+	r.parent = father
 	return r
 }
 
@@ -104,13 +174,27 @@ const (
 	UsageTag = "usage"
 	//ActTag is the struct field tag used to specify the field is an action
 	ActTag = "action"
+	//HiddenTag is the struct field tag used to hide the flag or action from usage and generated output,
+	//it still could be parsed; the value is a bool, empty means true;
+	//when used on a nested struct field, it applies to all fields of the nested struct unless overridden
+	HiddenTag = "hidden"
+	//GroupTag is the struct field tag used to specify the help section of the field,
+	//when used on a nested struct field, it is inherited by fields of the nested struct
+	GroupTag = "group"
+	//AliasesTag is the struct field tag used to specify a list of alternative names of an action, separated by ","
+	AliasesTag = "aliases"
 )
 
 // Fill filler with struct in
 func (filler *Filler) Fill(in any) error {
 	t := reflect.TypeOf(in)
 	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
-		return filler.walk(reflect.ValueOf(in), "", "", false)
+		filler.val = reflect.ValueOf(in)
+		err := filler.walk(filler.val, "", "", inheritedAttrs{}, "", false)
+		if err != nil {
+			return err
+		}
+		return filler.resolve()
 	} else {
 		return fmt.Errorf("only support a pointer to struct, but got %v", t)
 	}
@@ -165,8 +249,83 @@ func isFlagSupportedKind(k reflect.Kind) bool {
 	return false
 }
 
+// inheritedAttrs are the attributes of a nested struct field that are inherited by its fields
+type inheritedAttrs struct {
+	group  string
+	hidden bool
+}
+
+// inherit returns the attributes of the field with tag, inherited from attrs
+func (attrs inheritedAttrs) inherit(tag reflect.StructTag) (inheritedAttrs, error) {
+	r := attrs
+	if g, ok := tag.Lookup(GroupTag); ok {
+		r.group = g
+	}
+	var err error
+	if r.hidden, err = isHidden(tag, r.hidden); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// isHidden returns the value of hidden tag, or inherited if there is no hidden tag
+func isHidden(tag reflect.StructTag, inherited bool) (bool, error) {
+	v, ok := tag.Lookup(HiddenTag)
+	if !ok {
+		return inherited, nil
+	}
+	hidden, err := parseBoolTag(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %v tag %v, %w", HiddenTag, v, err)
+	}
+	return hidden, nil
+}
+
+// addFlagInfo records the metadata of flag name, which is created for the field ref points to
+func (filler *Filler) addFlagInfo(name string, attrs inheritedAttrs, tag reflect.StructTag, ref reflect.Value) {
+	info := &flagInfo{
+		name:   name,
+		group:  attrs.group,
+		hidden: attrs.hidden,
+		owner:  filler,
+		tag:    tag,
+		ref:    ref,
+	}
+	filler.flags = append(filler.flags, info)
+	filler.flagMap[name] = info
+}
+
+// resolve resolves the tags of flags in filler and its descendants,
+// it is called after the input struct is walked, so that a tag could reference any flag
+func (filler *Filler) resolve() error {
+	for _, info := range filler.flags {
+		if err := filler.setDeprecation(info); err != nil {
+			return err
+		}
+		if err := filler.resolveRules(info); err != nil {
+			return err
+		}
+		if err := info.resolvePathRule(); err != nil {
+			return err
+		}
+		if err := info.resolveCandidates(); err != nil {
+			return err
+		}
+		if err := info.resolveStrict(); err != nil {
+			return err
+		}
+	}
+	for _, childname := range filler.orderList {
+		if err := filler.fsMap[childname].resolve(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // in must be a pointer to struct
-func (filler *Filler) walk(inV reflect.Value, nameprefix, usage string, isAct bool) error {
+// attrs are the attributes inherited from the parent struct
+func (filler *Filler) walk(inV reflect.Value, nameprefix, usage string, attrs inheritedAttrs, tag reflect.StructTag, isAct bool) error {
 	fs := filler.fs
 	var err error
 	if inV.Kind() != reflect.Pointer {
@@ -183,11 +342,13 @@ func (filler *Filler) walk(inV reflect.Value, nameprefix, usage string, isAct bo
 	//check if it implements EncodingTextMarshaler inteface
 	if inT.Implements(textEncodingInt) {
 		setTextEncodingType(fs, inV, nameprefix, usage)
+		filler.addFlagInfo(nameprefix, attrs, tag, inV)
 		return nil
 	}
 	//these are kinds directly supported by flag module
 	if isFlagSupportedKind(ElemK) {
 		setStandardFlagType(fs, inV, nameprefix, usage)
+		filler.addFlagInfo(nameprefix, attrs, tag, inV)
 		return nil
 	}
 	switch ElemK {
@@ -212,6 +373,11 @@ func (filler *Filler) walk(inV reflect.Value, nameprefix, usage string, isAct bo
 				if alias != "" {
 					fname = alias
 				}
+				var fieldAttrs inheritedAttrs
+				fieldAttrs, err = attrs.inherit(fieldT.Tag)
+				if err != nil {
+					return fmt.Errorf("%v: %w", fieldT.Name, err)
+				}
 				if field.Kind() == reflect.Pointer {
 					if field.IsNil() {
 						//initilize the nil pointer
@@ -225,6 +391,7 @@ func (filler *Filler) walk(inV reflect.Value, nameprefix, usage string, isAct bo
 						field = field.Addr()
 					}
 					f(fs, field, fieldT.Tag, fname, usage)
+					filler.addFlagInfo(fname, fieldAttrs, fieldT.Tag, field)
 					continue
 				}
 				//check if it implements textMarshal
@@ -232,12 +399,14 @@ func (filler *Filler) walk(inV reflect.Value, nameprefix, usage string, isAct bo
 					if fieldT.Type.Implements(textEncodingInt) {
 						//pointer to textmarshale
 						setTextEncodingType(fs, field, fname, usage)
+						filler.addFlagInfo(fname, fieldAttrs, fieldT.Tag, field)
 						continue
 					}
 				} else {
 					if reflect.PointerTo(fieldT.Type).Implements(textEncodingInt) {
 						//textmarshale
 						setTextEncodingType(fs, field.Addr(), fname, usage)
+						filler.addFlagInfo(fname, fieldAttrs, fieldT.Tag, field.Addr())
 						continue
 					}
 				}
@@ -266,6 +435,7 @@ func (filler *Filler) walk(inV reflect.Value, nameprefix, usage string, isAct bo
 						if err != nil {
 							return err
 						}
+						filler.addFlagInfo(fname, fieldAttrs, fieldT.Tag, field.Addr())
 						continue
 					} else {
 						return fmt.Errorf("%v is a slice/array of unsupported type %v", fieldT.Name, fieldT)
@@ -276,28 +446,49 @@ func (filler *Filler) walk(inV reflect.Value, nameprefix, usage string, isAct bo
 				if fieldT.Type.Kind() == reflect.Struct ||
 					(fieldT.Type.Kind() == reflect.Pointer && fieldT.Type.Elem().Kind() == reflect.Struct) {
 					if _, ok := fieldT.Tag.Lookup(ActTag); ok {
-						if _, ok := filler.fsMap[fname]; ok {
+						if filler.isReservedActionName(fname) {
+							return fmt.Errorf("action %v collides with the built-in action %v", fname, fname)
+						}
+						if existing, ok := filler.fsMap[fname]; ok {
+							if existing.fs.Name() != fname {
+								return fmt.Errorf("action %v collides with an alias of action %v", fname, existing.fs.Name())
+							}
 							return fmt.Errorf("found struct type field with duplicate name %v", fname)
 						}
-						filler.fsMap[fname] = newInheritFiller(filler, fname, usage)
+						child := newInheritFiller(filler, fname, usage)
+						child.tag = fieldT.Tag
+						child.val = field
+						if field.Kind() != reflect.Pointer {
+							child.val = field.Addr()
+						}
+						//the tag is already checked by inherit
+						child.hidden, _ = isHidden(fieldT.Tag, false)
+						filler.fsMap[fname] = child
 						filler.translatedActNameMap[fname] = fieldT.Name
 						filler.orderList = append(filler.orderList, fname)
+						if err = filler.addActionAliases(child, fieldT.Name); err != nil {
+							return err
+						}
+						if err = filler.setActionAttrs(child, fname, fieldT.Tag); err != nil {
+							return err
+						}
 
 						// flag.NewFlagSet(fieldT.Name, filler.errHandle)
 
-						err = filler.fsMap[fname].walk(field, fname, usage, true)
+						err = filler.fsMap[fname].walk(field, fname, usage, inheritedAttrs{}, fieldT.Tag, true)
 						if err != nil {
 							return err
 						}
 						continue
 					}
 				}
-				err = filler.walk(field, fname, usage, false)
+				err = filler.walk(field, fname, usage, fieldAttrs, fieldT.Tag, false)
 				if err != nil {
 					return err
 				}
 			}
 		}
+		filler.addValidator(inV)
 	}
 	return nil
 }
@@ -326,7 +517,7 @@ L1:
 		switch state {
 		case stateArgDone:
 			if !hasdash {
-				if _, ok := filler.fsMap[arg]; ok {
+				if _, ok := filler.fsMap[arg]; ok || (arg == HelpActionName && filler.hasHelpAction()) {
 					return i, nil
 				} else {
 					return -1, fmt.Errorf(`found unrecognized action "%v"`, arg)
@@ -372,56 +563,130 @@ L1:
 	return -1, nil
 }
 
+// handleErr handles the inerr according to filler's flag.ErrorHandling,
+// under flag.ExitOnError, inerr is written to the output of filler's flagset before exiting
+func (filler *Filler) handleErr(inerr error) {
+	if inerr != nil && filler.errHandle == flag.ExitOnError {
+		fmt.Fprintln(filler.fs.Output(), inerr)
+	}
+	filler.handleParseErr(inerr)
+}
+
+// handleParseErr is like handleErr, but doesn't write inerr, which is returned by
+// filler's flagset and already written by it
+func (filler *Filler) handleParseErr(inerr error) {
+	if inerr != nil {
+		switch filler.errHandle {
+		case flag.ExitOnError:
+			os.Exit(2)
+		case flag.PanicOnError:
+			panic(inerr)
+		}
+	}
+}
+
 // ParseArgs parse the args, return parsed actions as a slice of string, each is a parsed action name
 func (filler *Filler) ParseArgs(args []string) ([]string, error) {
+	parsedActions, _, err := filler.parseAndCheck(args)
+	return parsedActions, err
+}
+
+// parseAndCheck parses the args and checks the parsed fillers, return parsed action names,
+// and the fillers on the parsed action path, starting with filler itself
+func (filler *Filler) parseAndCheck(args []string) ([]string, []*Filler, error) {
+	if len(args) > 0 && args[0] == CompleteActionName && filler.hasCompleteAction() {
+		return nil, nil, filler.runCompletion(args[1:])
+	}
+	parsedActions, parsedFillers, err := filler.parseArgs(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnDeprecated(parsedFillers)
+	err = checkRules(parsedFillers)
+	if err != nil {
+		filler.handleErr(err)
+		return nil, nil, err
+	}
+	err = checkPaths(parsedFillers)
+	if err != nil {
+		filler.handleErr(err)
+		return nil, nil, err
+	}
+	err = filler.runValidators(parsedActions, parsedFillers)
+	if err != nil {
+		filler.handleErr(err)
+		return nil, nil, err
+	}
+	return parsedActions, parsedFillers, nil
+}
+
+// parseArgs parse the args, return parsed action names, and the fillers on the parsed action path,
+// starting with filler itself
+func (filler *Filler) parseArgs(args []string) ([]string, []*Filler, error) {
 	parsedActions := []string{}
+	parsedFillers := []*Filler{filler}
 	var nextActPos int = -1
 	var nextAct string
 	var err error
-	errHanlder := func(inerr error) {
-		if inerr != nil {
-			switch filler.errHandle {
-			case flag.ExitOnError:
-				fmt.Println("-----?", err)
-				os.Exit(2)
-			case flag.PanicOnError:
-				panic(err)
-			}
-		}
-
-	}
 	nextActPos, err = filler.getNextActPosState(args)
 	if err != nil {
-		errHanlder(err)
-		return nil, err
+		filler.handleErr(err)
+		return nil, nil, err
 	}
 	if nextActPos >= 0 {
 		nextAct = args[nextActPos]
 	}
 	endPos := len(args)
+	//childArgs are the args following the next action
+	childArgs := []string{}
 	if nextActPos >= 0 {
 		endPos = nextActPos
+		childArgs = args[endPos+1:]
 	}
 	err = filler.fs.Parse(args[:endPos])
 	if err != nil {
-		return nil, err
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, nil, &HelpError{Actions: []string{}}
+		}
+		filler.handleParseErr(err)
+		return nil, nil, err
 	}
-	if nextActPos >= 0 {
+	if nextActPos >= 0 && nextAct == HelpActionName && filler.hasHelpAction() {
+		err = filler.runHelpAction(childArgs)
+		if !errors.Is(err, flag.ErrHelp) {
+			filler.handleErr(err)
+		}
+		return nil, nil, err
+	}
+	if nextActPos < 0 {
+		if err = filler.actionRequiredErr(); err != nil {
+			filler.handleErr(err)
+			return nil, nil, err
+		}
+		nextAct = filler.defaultAction
+	}
+	if nextAct != "" {
 		if nextFiller, ok := filler.fsMap[nextAct]; !ok {
 			err = fmt.Errorf("%w: %v", ErrInvalidAction, nextAct)
-			errHanlder(err)
-			return nil, err
+			filler.handleErr(err)
+			return nil, nil, err
 		} else {
 			parsedActions = append(parsedActions, filler.translatedActNameMap[nextAct])
-			acts, err := nextFiller.ParseArgs(args[endPos+1:])
+			acts, fillers, err := nextFiller.parseArgs(childArgs)
 			if err != nil {
-				errHanlder(err)
-				return nil, err
+				var herr *HelpError
+				if errors.As(err, &herr) {
+					herr.Actions = append([]string{filler.translatedActNameMap[nextAct]}, herr.Actions...)
+					return nil, nil, herr
+				}
+				filler.handleErr(err)
+				return nil, nil, err
 			}
 			parsedActions = append(parsedActions, acts...)
+			parsedFillers = append(parsedFillers, fillers...)
 		}
 	}
-	return parsedActions, nil
+	return parsedActions, parsedFillers, nil
 }
 
 // GetActUsage returns filler's child action usage specified by actname,
@@ -436,24 +701,15 @@ func (filler *Filler) GetActUsage(actname string) string {
 	return ""
 }
 
-// UsageStr return a usage string for the filler and its descendant fillers (a.k.a actions)
+// UsageStr return a usage string for the filler and its descendant fillers (a.k.a actions),
+// rendered by the usage template
 func (filler *Filler) UsageStr(prefix string) string {
 	step := "  "
 	indent := prefix + step
 	buf := new(bytes.Buffer)
-	fmt.Fprintln(buf, filler.usage)
-	filler.fs.VisitAll(func(f *flag.Flag) {
-		fmt.Fprintf(buf, "%v- %v: %v\n", indent, f.Name,
-			// reflect.Indirect(reflect.ValueOf(f.Value)).Kind(),
-			f.Usage)
-		if f.DefValue != "" {
-			fmt.Fprintf(buf, "%v\tdefault:%v\n", indent, f.DefValue)
-		}
-	})
-	for _, childname := range filler.orderList {
-		child := filler.fsMap[childname]
-		fmt.Fprintf(buf, "%v= %v: ", indent, childname)
-		fmt.Fprint(buf, child.UsageStr(indent))
+	err := filler.usageTemplate.Execute(buf, filler.usageData(indent))
+	if err != nil {
+		fmt.Fprintf(buf, "failed to render usage, %v\n", err)
 	}
 	return buf.String()
 }
@@ -1,13 +1,20 @@
 package myflags_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net/netip"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"text/template"
 	"time"
 
 	"github.com/hujun-open/myflags"
@@ -427,3 +434,1594 @@ func deepEqual(in, expect any) bool {
 	// fmt.Println(3333333333, valIn.Interface(), valExpect.Interface())
 	return reflect.DeepEqual(valIn.Interface(), valExpect.Interface())
 }
+
+type RuleTestStruct struct {
+	TLSCert string
+	TLSKey  string `requires:"tlscert"`
+	Output  string
+	Act     struct {
+		DryRun  bool   `conflicts:"output"`
+		Profile string `requires:"tlscert,tlskey"`
+	} `action:""`
+}
+
+func TestRequiresConflicts(t *testing.T) {
+	caseList := []struct {
+		args       []string
+		shouldFail bool
+	}{
+		{args: []string{"-tlskey", "a.key", "-tlscert", "a.cert"}},
+		{args: []string{"-tlskey", "a.key"}, shouldFail: true},
+		{args: []string{"-output", "out", "act"}},
+		{args: []string{"-output", "out", "act", "-dryrun"}, shouldFail: true},
+		{args: []string{"act", "-profile", "p"}, shouldFail: true},
+		{args: []string{"-tlskey", "a.key", "-tlscert", "a.cert", "act", "-profile", "p"}},
+	}
+	for i, c := range caseList {
+		filler := myflags.NewFiller("test", "", myflags.WithFlagErrHandling(flag.ContinueOnError))
+		if err := filler.Fill(new(RuleTestStruct)); err != nil {
+			t.Fatal(err)
+		}
+		_, err := filler.ParseArgs(c.args)
+		if (err != nil) != c.shouldFail {
+			t.Fatalf("case %d: unexpected result, err: %v", i, err)
+		}
+		t.Logf("case %d: %v", i, err)
+	}
+	filler := myflags.NewFiller("test", "", myflags.WithFlagErrHandling(flag.ContinueOnError))
+	err := filler.Fill(&struct {
+		Key string `requires:"nosuchflag"`
+	}{})
+	if err == nil {
+		t.Fatal("unknown flag reference should fail")
+	}
+}
+
+type validateLog []string
+
+type ValidateNested struct {
+	Port int
+	log  *validateLog
+}
+
+func (vn *ValidateNested) Validate() error {
+	*vn.log = append(*vn.log, "nested")
+	if vn.Port > 65535 {
+		return fmt.Errorf("invalid port %d", vn.Port)
+	}
+	return nil
+}
+
+type ValidateAct struct {
+	Level int
+	log   *validateLog
+}
+
+func (va *ValidateAct) Validate() error {
+	*va.log = append(*va.log, "act")
+	if va.Level < 0 {
+		return fmt.Errorf("invalid level %d", va.Level)
+	}
+	return nil
+}
+
+type ValidateRoot struct {
+	Server ValidateNested
+	Act    ValidateAct `action:""`
+	Other  ValidateAct `action:""`
+	log    *validateLog
+}
+
+func (vr *ValidateRoot) Validate() error {
+	*vr.log = append(*vr.log, "root")
+	return nil
+}
+
+func newValidateRoot() *ValidateRoot {
+	log := new(validateLog)
+	r := &ValidateRoot{log: log}
+	r.Server.log = log
+	r.Act.log = log
+	r.Other.log = log
+	return r
+}
+
+func TestValidate(t *testing.T) {
+	in := newValidateRoot()
+	filler := myflags.NewFiller("test", "", myflags.WithFlagErrHandling(flag.ContinueOnError))
+	if err := filler.Fill(in); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := filler.ParseArgs([]string{"act", "-level", "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(*in.log, []string{"act", "nested", "root"}) {
+		t.Fatalf("unexpected validate order %v", *in.log)
+	}
+
+	in = newValidateRoot()
+	filler = myflags.NewFiller("test", "", myflags.WithFlagErrHandling(flag.ContinueOnError),
+		myflags.WithValidateOrder(myflags.ValidateRootFirst))
+	if err := filler.Fill(in); err != nil {
+		t.Fatal(err)
+	}
+	_, err := filler.ParseArgs([]string{"act", "-level", "-1"})
+	var verr *myflags.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expect a ValidationError, got %v", err)
+	}
+	if !slices.Equal(verr.Actions, []string{"Act"}) {
+		t.Fatalf("unexpected action path %v", verr.Actions)
+	}
+	if !slices.Equal(*in.log, []string{"root", "nested", "act"}) {
+		t.Fatalf("unexpected validate order %v", *in.log)
+	}
+}
+
+func TestPathTag(t *testing.T) {
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "a.conf")
+	if err := os.WriteFile(fname, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MYFLAGS_TEST_DIR", dir)
+	type pathStruct struct {
+		Config string `path:"file,exists,readable"`
+		OutDir string `path:"dir,writable"`
+		New    string `path:"notexists"`
+	}
+	caseList := []struct {
+		args       []string
+		shouldFail bool
+	}{
+		{args: []string{"-config", fname, "-outdir", dir}},
+		{args: []string{"-config", "$MYFLAGS_TEST_DIR/a.conf"}},
+		{args: []string{"-config", dir}, shouldFail: true},
+		{args: []string{"-config", filepath.Join(dir, "nosuchfile")}, shouldFail: true},
+		{args: []string{"-outdir", fname}, shouldFail: true},
+		{args: []string{"-new", fname}, shouldFail: true},
+	}
+	for i, c := range caseList {
+		in := new(pathStruct)
+		filler := myflags.NewFiller("test", "", myflags.WithFlagErrHandling(flag.ContinueOnError),
+			myflags.WithPathExpansion(true))
+		if err := filler.Fill(in); err != nil {
+			t.Fatal(err)
+		}
+		_, err := filler.ParseArgs(c.args)
+		if (err != nil) != c.shouldFail {
+			t.Fatalf("case %d: unexpected result, err: %v", i, err)
+		}
+		if err != nil && !errors.Is(err, myflags.ErrInvalidPath) {
+			t.Fatalf("case %d: error should wrap ErrInvalidPath, %v", i, err)
+		}
+		t.Logf("case %d: %v", i, err)
+	}
+	//writable check must not create anything in the directory
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("unexpected entries in %v: %v", dir, entries)
+	}
+	filler := myflags.NewFiller("test", "", myflags.WithFlagErrHandling(flag.ContinueOnError))
+	if err := filler.Fill(&struct {
+		Count int `path:"file"`
+	}{}); err == nil {
+		t.Fatal("path tag on int field should fail")
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	type depStruct struct {
+		Skip    bool   `oldnames:"s,skipit"`
+		Profile string `deprecated:"use -skip instead"`
+	}
+	in := new(depStruct)
+	warnBuf := new(strings.Builder)
+	filler := myflags.NewFiller("test", "", myflags.WithFlagErrHandling(flag.ContinueOnError),
+		myflags.WithWarningWriter(warnBuf))
+	if err := filler.Fill(in); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := filler.ParseArgs([]string{"-s", "-profile", "p"}); err != nil {
+		t.Fatal(err)
+	}
+	if !in.Skip || in.Profile != "p" {
+		t.Fatalf("unexpected result %+v", in)
+	}
+	expected := "warning: flag -profile is deprecated, use -skip instead\nwarning: flag -s is renamed to -skip\n"
+	if warnBuf.String() != expected {
+		t.Fatalf("unexpected warning:\n%v", warnBuf.String())
+	}
+	usage := filler.UsageStr("")
+	if strings.Contains(usage, "profile") || strings.Contains(usage, "skipit") {
+		t.Fatalf("deprecated flags should be hidden:\n%v", usage)
+	}
+
+	filler = myflags.NewFiller("test", "", myflags.WithShowDeprecated(true))
+	if err := filler.Fill(new(depStruct)); err != nil {
+		t.Fatal(err)
+	}
+	usage = filler.UsageStr("")
+	if !strings.Contains(usage, "- skipit: renamed to -skip") {
+		t.Fatalf("deprecated flags should be listed:\n%v", usage)
+	}
+
+	if err := myflags.NewFiller("test", "").Fill(&struct {
+		Skip bool `oldnames:"s"`
+		S    string
+	}{}); err == nil {
+		t.Fatal("old name colliding with a later flag should fail")
+	}
+	if err := myflags.NewFiller("test", "").Fill(&struct {
+		S    string
+		Skip bool `oldnames:"s"`
+	}{}); err == nil {
+		t.Fatal("old name colliding with an earlier flag should fail")
+	}
+	if err := myflags.NewFiller("test", "").Fill(&struct {
+		Skip bool `oldnames:"x"`
+		Exit bool `oldnames:"x"`
+	}{}); err == nil {
+		t.Fatal("old name colliding with another old name should fail")
+	}
+}
+
+func TestStrictNumber(t *testing.T) {
+	type numStruct struct {
+		Hex    uint16  `base:"16" strict:""`
+		Small  int8    `strict:""`
+		Big    uint32  `strict:"" digitsep:""`
+		Ratio  float64 `strict:""`
+		Ratio2 float32
+		Loose  uint16 `base:"16"`
+	}
+	caseList := []struct {
+		args       []string
+		expected   numStruct
+		shouldFail bool
+	}{
+		{args: []string{"-hex", "ff"}, expected: numStruct{Hex: 0xff}},
+		{args: []string{"-hex", "0xff"}, expected: numStruct{Hex: 0xff}},
+		{args: []string{"-hex", "10000"}, shouldFail: true},
+		{args: []string{"-small", "-129"}, shouldFail: true},
+		{args: []string{"-big", "1_000_000"}, expected: numStruct{Big: 1000000}},
+		{args: []string{"-ratio", "abc"}, shouldFail: true},
+		{args: []string{"-ratio2", "abc"}, expected: numStruct{}},
+		{args: []string{"-loose", "0x10"}, expected: numStruct{Loose: 0x10}},
+		{args: []string{"-loose", "10"}, expected: numStruct{Loose: 10}},
+	}
+	for i, c := range caseList {
+		in := new(numStruct)
+		filler := myflags.NewFiller("test", "", myflags.WithFlagErrHandling(flag.ContinueOnError))
+		filler.GetFlagset().SetOutput(io.Discard)
+		if err := filler.Fill(in); err != nil {
+			t.Fatal(err)
+		}
+		_, err := filler.ParseArgs(c.args)
+		if (err != nil) != c.shouldFail {
+			t.Fatalf("case %d: unexpected result, err: %v", i, err)
+		}
+		if err == nil && *in != c.expected {
+			t.Fatalf("case %d: got %+v, expect %+v", i, *in, c.expected)
+		}
+		t.Logf("case %d: %v", i, err)
+	}
+	if err := myflags.NewFiller("test", "").Fill(&struct {
+		Hex uint16 `base:"16" strict:"yes"`
+	}{}); err == nil {
+		t.Fatal("invalid strict tag should fail")
+	}
+	//out of range error reports the input as specified
+	strictIn := &struct {
+		Hex uint32 `base:"16" strict:"" digitsep:""`
+	}{}
+	filler := myflags.NewFiller("test", "", myflags.WithFlagErrHandling(flag.ContinueOnError))
+	filler.GetFlagset().SetOutput(io.Discard)
+	if err := filler.Fill(strictIn); err != nil {
+		t.Fatal(err)
+	}
+	_, err := filler.ParseArgs([]string{"-hex", "0x1_0000_0000"})
+	if err == nil || !strings.Contains(err.Error(), "0x1_0000_0000 is out of range of uint32") {
+		t.Fatalf("out of range error should include the input, got %v", err)
+	}
+	if err := myflags.NewFiller("test", "").Fill(&struct {
+		Num uint16 `base:"7" strict:""`
+	}{}); err == nil {
+		t.Fatal("unsupported base of a strict field should fail")
+	}
+	in := &struct {
+		Hex uint16 `base:"16" strict:"false"`
+	}{}
+	filler = myflags.NewFiller("test", "", myflags.WithFlagErrHandling(flag.ContinueOnError))
+	if err := filler.Fill(in); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := filler.ParseArgs([]string{"-hex", "10"}); err != nil || in.Hex != 10 {
+		t.Fatalf("strict:\"false\" should parse loosely, got %v, %v", in.Hex, err)
+	}
+}
+
+func TestIntBaseDefault(t *testing.T) {
+	in := &struct {
+		Bin  uint8  `base:"2"`
+		Perm uint32 `base:"8"`
+		Hex  uint16 `base:"16"`
+	}{Bin: 5, Perm: 0o755, Hex: 0xff}
+	filler := myflags.NewFiller("test", "", myflags.WithFlagErrHandling(flag.ContinueOnError))
+	if err := filler.Fill(in); err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]string{"bin": "0b101", "perm": "0o755", "hex": "0xff"} {
+		if f := filler.GetFlagset().Lookup(name); f.DefValue != expected {
+			t.Fatalf("default value of -%v should be %v, got %v", name, expected, f.DefValue)
+		}
+	}
+	if _, err := filler.ParseArgs([]string{"-perm", "0o644"}); err != nil || in.Perm != 0o644 {
+		t.Fatalf("failed to parse octal value, got %o, %v", in.Perm, err)
+	}
+}
+
+func TestUsageTemplate(t *testing.T) {
+	type tmplStruct struct {
+		Name string `usage:"user name"`
+		Act  struct {
+			Count int `usage:"count"`
+		} `usage:"an action" action:""`
+	}
+	tmpl := template.Must(template.New("custom").Parse(
+		`{{.Name}}: {{.Description}}
+{{range .Flags}}{{.Name}} <{{.Type}}> {{.Usage}}
+{{end}}{{range .Actions}}{{template "custom" .}}{{end}}`))
+	filler := myflags.NewFiller("test", "a test", myflags.WithUsageTemplate(tmpl))
+	if err := filler.Fill(new(tmplStruct)); err != nil {
+		t.Fatal(err)
+	}
+	expected := "test: a test\nname <string> user name\nact: an action\ncount <int> count\n"
+	if usage := filler.UsageStr(""); usage != expected {
+		t.Fatalf("unexpected usage:\n%v", usage)
+	}
+}
+
+func TestActionHelp(t *testing.T) {
+	type helpStruct struct {
+		Config   string `usage:"config file"`
+		Compress struct {
+			Loop    int `usage:"loop count"`
+			ZipFile struct {
+				Name string `usage:"file name"`
+			} `usage:"zip a file" action:""`
+		} `usage:"to compress things" action:""`
+	}
+	caseList := []struct {
+		args         []string
+		expectedActs []string
+		contains     []string
+		excludes     []string
+	}{
+		{
+			args:         []string{"-h"},
+			expectedActs: []string{},
+			contains:     []string{"- config  <string>  config file", "= compress: to compress things", "= help:"},
+			excludes:     []string{"- loop"},
+		},
+		{
+			//help of compress is written to output of its own flagset
+			args:         []string{"compress", "-h"},
+			expectedActs: []string{"Compress"},
+		},
+		{
+			args:         []string{"help", "compress"},
+			expectedActs: []string{"Compress"},
+			contains:     []string{"Usage: test compress", "- loop  <int>  loop count", "flags of test:", "= zipfile: zip a file"},
+			excludes:     []string{"- name", "= help:"},
+		},
+		{
+			args:         []string{"help", "compress", "ZipFile"},
+			expectedActs: []string{"Compress", "ZipFile"},
+			contains:     []string{"Usage: test compress zipfile [flags]\n", "- name  <string>  file name", "flags of test compress:"},
+		},
+	}
+	for i, c := range caseList {
+		buf := new(strings.Builder)
+		filler := myflags.NewFiller("test", "", myflags.WithFlagErrHandling(flag.ContinueOnError))
+		if err := filler.Fill(new(helpStruct)); err != nil {
+			t.Fatal(err)
+		}
+		filler.GetFlagset().SetOutput(buf)
+		_, err := filler.ParseArgs(c.args)
+		var herr *myflags.HelpError
+		if !errors.As(err, &herr) || !errors.Is(err, flag.ErrHelp) {
+			t.Fatalf("case %d: expect a HelpError, got %v", i, err)
+		}
+		if !slices.Equal(herr.Actions, c.expectedActs) {
+			t.Fatalf("case %d: unexpected action path %v", i, herr.Actions)
+		}
+		for _, s := range c.contains {
+			if !strings.Contains(buf.String(), s) {
+				t.Fatalf("case %d: help should contain %q:\n%v", i, s, buf.String())
+			}
+		}
+		for _, s := range c.excludes {
+			if strings.Contains(buf.String(), s) {
+				t.Fatalf("case %d: help should not contain %q:\n%v", i, s, buf.String())
+			}
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	type groupStruct struct {
+		Verbose bool
+		Port    int    `group:"Network" usage:"port"`
+		Name    string `usage:"name"`
+		TLS     struct {
+			Cert string `usage:"cert file"`
+			Key  string `usage:"key file" group:"Security"`
+		} `group:"Network"`
+		Debug bool `group:"Security"`
+	}
+	filler := myflags.NewFiller("test", "a test", myflags.WithUsageWidth(80))
+	if err := filler.Fill(new(groupStruct)); err != nil {
+		t.Fatal(err)
+	}
+	expected := `a test
+  - verbose             default: false
+  - name      <string>  name
+  Network:
+  - port      <int>     port
+                        default: 0
+  - tls-cert  <string>  cert file
+  Security:
+  - tls-key   <string>  key file
+  - debug               default: false
+`
+	if usage := filler.UsageStr(""); usage != expected {
+		t.Fatalf("unexpected usage:\n%v", usage)
+	}
+
+	filler = myflags.NewFiller("test", "a test", myflags.WithUsageWidth(80),
+		myflags.WithFlagOrder(myflags.FlagOrderAlphabetical))
+	if err := filler.Fill(new(groupStruct)); err != nil {
+		t.Fatal(err)
+	}
+	expected = `a test
+  - name      <string>  name
+  - verbose             default: false
+  Network:
+  - port      <int>     port
+                        default: 0
+  - tls-cert  <string>  cert file
+  Security:
+  - debug               default: false
+  - tls-key   <string>  key file
+`
+	if usage := filler.UsageStr(""); usage != expected {
+		t.Fatalf("unexpected usage:\n%v", usage)
+	}
+
+	//flags added via GetFlagset are listed after the declared flags of the default section, sorted by name
+	filler = myflags.NewFiller("test", "a test", myflags.WithUsageWidth(80))
+	if err := filler.Fill(new(groupStruct)); err != nil {
+		t.Fatal(err)
+	}
+	filler.GetFlagset().String("extra", "x", "an extra flag")
+	filler.GetFlagset().Bool("added", false, "an added flag")
+	expected = `a test
+  - verbose             default: false
+  - name      <string>  name
+  - added               an added flag
+                        default: false
+  - extra               an extra flag
+                        default: x
+  Network:
+`
+	if usage := filler.UsageStr(""); !strings.HasPrefix(usage, expected) {
+		t.Fatalf("unexpected usage:\n%v", usage)
+	}
+	if help := filler.HelpStr(); !strings.Contains(help, "  - extra               an extra flag\n") {
+		t.Fatalf("help should contain the extra flag:\n%v", help)
+	}
+}
+
+func TestHidden(t *testing.T) {
+	type hiddenStruct struct {
+		Name  string `usage:"name"`
+		Debug bool   `hidden:""`
+		Trace struct {
+			Level int
+		} `hidden:""`
+		Maintain struct {
+			Force bool
+		} `action:"" hidden:""`
+	}
+	in := new(hiddenStruct)
+	filler := myflags.NewFiller("test", "a test", myflags.WithFlagErrHandling(flag.ContinueOnError))
+	if err := filler.Fill(in); err != nil {
+		t.Fatal(err)
+	}
+	usage := filler.UsageStr("")
+	for _, s := range []string{"debug", "trace-level", "maintain"} {
+		if strings.Contains(usage, s) {
+			t.Fatalf("%v should be hidden:\n%v", s, usage)
+		}
+	}
+	acts, err := filler.ParseArgs([]string{"-debug", "-trace-level", "2", "maintain", "-force"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !in.Debug || in.Trace.Level != 2 || !in.Maintain.Force || !slices.Equal(acts, []string{"Maintain"}) {
+		t.Fatalf("hidden flags should be parsed, %+v, %v", in, acts)
+	}
+	t.Setenv(myflags.ShowHiddenEnv, "1")
+	usage = filler.UsageStr("")
+	for _, s := range []string{"debug", "trace-level", "= maintain"} {
+		if !strings.Contains(usage, s) {
+			t.Fatalf("%v should be shown:\n%v", s, usage)
+		}
+	}
+
+	t.Setenv(myflags.ShowHiddenEnv, "")
+	type notHiddenStruct struct {
+		Debug bool `hidden:"false"`
+		Trace struct {
+			Level int
+			Dump  bool `hidden:"false"`
+		} `hidden:"true"`
+		Maintain struct{} `action:"" hidden:"false"`
+	}
+	filler = myflags.NewFiller("test", "a test")
+	if err := filler.Fill(new(notHiddenStruct)); err != nil {
+		t.Fatal(err)
+	}
+	usage = filler.UsageStr("")
+	for _, s := range []string{"- debug", "- trace-dump", "= maintain"} {
+		if !strings.Contains(usage, s) {
+			t.Fatalf("%v should be shown:\n%v", s, usage)
+		}
+	}
+	if strings.Contains(usage, "trace-level") {
+		t.Fatalf("trace-level should be hidden:\n%v", usage)
+	}
+	if err := myflags.NewFiller("test", "").Fill(&struct {
+		Debug bool `hidden:"maybe"`
+	}{}); err == nil {
+		t.Fatal("invalid hidden tag should fail")
+	}
+}
+
+type ExampleAct struct {
+	Folder string `usage:"folder name"`
+}
+
+func (ea *ExampleAct) Description() string {
+	return "zip a folder recursively,\nsymbol links are not followed"
+}
+
+func (ea *ExampleAct) Examples() []myflags.Example {
+	return []myflags.Example{
+		{Args: "-folder ./data", Usage: "zip folder ./data"},
+	}
+}
+
+func TestExamples(t *testing.T) {
+	type exampleStruct struct {
+		Compress struct {
+			ZipFolder ExampleAct `usage:"zip a folder" action:""`
+			ZipFile   struct {
+				Name string
+			} `usage:"zip a file" action:"" description:"zip a single file" examples:"-name a.txt;-name b.txt"`
+		} `action:""`
+	}
+	filler := myflags.NewFiller("cptool", "", myflags.WithFlagErrHandling(flag.ContinueOnError),
+		myflags.WithUsageWidth(80))
+	if err := filler.Fill(new(exampleStruct)); err != nil {
+		t.Fatal(err)
+	}
+	caseList := []struct {
+		args     []string
+		expected string
+	}{
+		{
+			args: []string{"help", "compress", "zipfolder"},
+			expected: `Usage: cptool compress zipfolder [flags]
+zip a folder
+zip a folder recursively,
+symbol links are not followed
+  - folder  <string>  folder name
+  examples:
+    cptool compress zipfolder -folder ./data
+    	zip folder ./data
+`,
+		},
+		{
+			args: []string{"help", "compress", "zipfile"},
+			expected: `Usage: cptool compress zipfile [flags]
+zip a file
+zip a single file
+  - name  <string>
+  examples:
+    cptool compress zipfile -name a.txt
+    cptool compress zipfile -name b.txt
+`,
+		},
+	}
+	for i, c := range caseList {
+		buf := new(strings.Builder)
+		filler.GetFlagset().SetOutput(buf)
+		if _, err := filler.ParseArgs(c.args); !errors.Is(err, flag.ErrHelp) {
+			t.Fatalf("case %d: expect help error, got %v", i, err)
+		}
+		if buf.String() != c.expected {
+			t.Fatalf("case %d: unexpected help:\n%v", i, buf.String())
+		}
+	}
+}
+
+func TestUsageWrap(t *testing.T) {
+	type wrapStruct struct {
+		Name    string        `usage:"the name of the user, which is used to look up the profile in the database"`
+		Timeout time.Duration `usage:"timeout of the request\nzero means no timeout"`
+	}
+	in := &wrapStruct{Timeout: time.Second}
+	expected := `a test
+  - name     <string>    the name of the user, which is used
+                         to look up the profile in the
+                         database
+  - timeout  <duration>  timeout of the request
+                         zero means no timeout
+                         default: 1s
+`
+	filler := myflags.NewFiller("test", "a test", myflags.WithUsageWidth(60))
+	if err := filler.Fill(in); err != nil {
+		t.Fatal(err)
+	}
+	if usage := filler.UsageStr(""); usage != expected {
+		t.Fatalf("unexpected usage:\n%v", usage)
+	}
+	//width from $COLUMNS, with minimal width fallback
+	t.Setenv("COLUMNS", "10")
+	filler = myflags.NewFiller("test", "a test")
+	if err := filler.Fill(in); err != nil {
+		t.Fatal(err)
+	}
+	expected = `a test
+  - name     <string>    the name of the
+                         user, which is used
+                         to look up the
+                         profile in the
+                         database
+  - timeout  <duration>  timeout of the
+                         request
+                         zero means no
+                         timeout
+                         default: 1s
+`
+	if usage := filler.UsageStr(""); usage != expected {
+		t.Fatalf("unexpected usage:\n%v", usage)
+	}
+}
+
+type ManTestStruct struct {
+	ConfigFile string `usage:"working profile"`
+	Compress   struct {
+		Loop    uint `usage:"number of compress iterations"`
+		ZipFile struct {
+			FileName string `alias:"f" usage:"specify file name" group:"Input"`
+		} `usage:"zip a file" action:"" examples:"-f a.txt"`
+	} `usage:"to compress things" action:""`
+}
+
+func TestManPage(t *testing.T) {
+	filler := myflags.NewFiller("cptool", "a zip command")
+	if err := filler.Fill(&ManTestStruct{ConfigFile: "default.conf"}); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	err := filler.WriteManPage(buf, 1, myflags.ManMeta{Date: "2024-01-01", Source: "cptool 1.0", Manual: "User Commands", Combined: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `.TH "CPTOOL" "1" "2024\-01\-01" "cptool 1.0" "User Commands"
+.SH NAME
+cptool \- a zip command
+.SH SYNOPSIS
+.B cptool
+[\fIflags\fR]
+\fIaction\fR ...
+.SH DESCRIPTION
+a zip command
+.SH OPTIONS
+.TP
+.B \-configfile \fI<string>\fR
+working profile
+.br
+Default: default.conf
+.SH COMMANDS
+.SS cptool compress
+to compress things
+.TP
+.B \-loop \fI<uint>\fR
+number of compress iterations
+.br
+Default: 0
+.SS cptool compress zipfile
+zip a file
+.PP
+\fBInput\fR
+.TP
+.B \-f \fI<string>\fR
+specify file name
+.PP
+.nf
+.RS
+cptool compress zipfile \-f a.txt
+.RE
+.fi
+`
+	if buf.String() != expected {
+		t.Fatalf("unexpected man page:\n%v", buf.String())
+	}
+
+	dir := t.TempDir()
+	if err = filler.WriteManPages(dir, 1, myflags.ManMeta{}); err != nil {
+		t.Fatal(err)
+	}
+	page, err := os.ReadFile(filepath.Join(dir, "cptool-compress.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `.TH "CPTOOL\-COMPRESS" "1" "" "" ""
+.SH NAME
+cptool\-compress \- to compress things
+.SH SYNOPSIS
+.B cptool compress
+[\fIflags\fR]
+\fIaction\fR ...
+.SH DESCRIPTION
+to compress things
+.SH OPTIONS
+.TP
+.B \-loop \fI<uint>\fR
+number of compress iterations
+.br
+Default: 0
+.SH OPTIONS OF CPTOOL
+.TP
+.B \-configfile \fI<string>\fR
+working profile
+.br
+Default: default.conf
+.SH COMMANDS
+.TP
+.B zipfile
+zip a file
+.SH SEE ALSO
+\fBcptool\fR(1),
+\fBcptool\-compress\-zipfile\fR(1)
+`
+	if string(page) != expected {
+		t.Fatalf("unexpected man page:\n%v", string(page))
+	}
+	for _, name := range []string{"cptool.1", "cptool-compress-zipfile.1"} {
+		if _, err = os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	filler := myflags.NewFiller("cptool", "a zip command")
+	if err := filler.Fill(&ManTestStruct{ConfigFile: "default.conf"}); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := filler.WriteMarkdown(buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"- [cptool compress zipfile](#cptool-compress-zipfile): zip a file\n",
+		"## cptool compress\n",
+		"| `-configfile` | `string` | `default.conf` | working profile |\n",
+		"| [zipfile](#cptool-compress-zipfile) | zip a file |\n",
+		"#### Input\n",
+		"```\ncptool compress zipfile -f a.txt\n```\n",
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("markdown should contain %q:\n%v", s, buf.String())
+		}
+	}
+
+	dir := filepath.Join(t.TempDir(), "docs")
+	if err := filler.WriteMarkdownFiles(dir); err != nil {
+		t.Fatal(err)
+	}
+	index, err := os.ReadFile(filepath.Join(dir, myflags.MarkdownIndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(index), "- [cptool compress](cptool_compress.md): to compress things\n") {
+		t.Fatalf("unexpected index:\n%v", string(index))
+	}
+	page, err := os.ReadFile(filepath.Join(dir, "cptool_compress.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(page), "# cptool compress\n") ||
+		!strings.Contains(string(page), "| [zipfile](cptool_compress_zipfile.md) | zip a file |\n") {
+		t.Fatalf("unexpected page:\n%v", string(page))
+	}
+}
+
+func TestPlaceholder(t *testing.T) {
+	type placeholderStruct struct {
+		Loop      uint16    `base:"16"`
+		Time      time.Time `layout:"2006 02 Jan 15:04"`
+		AddrSlice []*netip.Addr
+		Addr      netip.Addr
+		Ratio     float32
+		Skip      bool
+		SNL       SubnoList
+		Config    string `placeholder:"file"`
+	}
+	tmpl := template.Must(template.New("placeholder").Parse(
+		`{{range .Flags}}-{{.Name}} {{.Placeholder}}
+{{end}}`))
+	filler := myflags.NewFiller("test", "", myflags.WithUsageTemplate(tmpl))
+	if err := filler.Fill(new(placeholderStruct)); err != nil {
+		t.Fatal(err)
+	}
+	expected := `-loop <uint16, hex>
+-time <2006 02 Jan 15:04>
+-addrslice <ip,...>
+-addr <ip>
+-ratio <float32>
+-skip 
+-snl <subnolist>
+-config <file>
+`
+	if usage := filler.UsageStr(""); usage != expected {
+		t.Fatalf("unexpected placeholders:\n%v", usage)
+	}
+}
+
+func TestBashCompletion(t *testing.T) {
+	type completionStruct struct {
+		ConfigFile string `usage:"working profile"`
+		Verbose    bool
+		Secret     string `hidden:""`
+		Old        string `deprecated:"use -configfile"`
+		Compress   struct {
+			Loop    uint
+			ZipFile struct {
+				FileName string `alias:"f"`
+			} `action:""`
+		} `action:""`
+		Debug struct{} `action:"" hidden:""`
+	}
+	filler := myflags.NewFiller("cptool", "a zip command")
+	if err := filler.Fill(&completionStruct{}); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := filler.GenBashCompletion(buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"    '')\n        flags='configfile verbose'\n        values='configfile old secret'\n        actions='compress'\n",
+		"    'compress')\n        flags='loop'\n        values='loop'\n        actions='zipfile'\n",
+		"    'compress zipfile')\n        flags='f'\n",
+		"local helpaction='help'",
+		"complete -F _cptool 'cptool'\n",
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("bash completion should contain %q:\n%v", s, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "debug") {
+		t.Fatalf("bash completion should not contain hidden action:\n%v", buf.String())
+	}
+}
+
+func TestZshCompletion(t *testing.T) {
+	type zshStruct struct {
+		ConfigFile string `usage:"working profile [x]" path:"file" conflicts:"verbose"`
+		Verbose    bool
+		Secret     string `hidden:""`
+		Compress   struct {
+			Loop    uint `usage:"number of compress iterations"`
+			ZipFile struct {
+				Folder string `path:"dir"`
+			} `usage:"zip a file" action:""`
+		} `usage:"to compress things" action:""`
+	}
+	filler := myflags.NewFiller("cptool", "a zip command")
+	if err := filler.Fill(&zshStruct{}); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := filler.GenZshCompletion(buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"#compdef cptool\n",
+		"'(-verbose)-configfile=[working profile \\[x\\]]:<string>:_cptool_value' \\\n",
+		"'(-configfile)-verbose' \\\n",
+		"'compress:to compress things'\n",
+		"'help:show help of an action'\n",
+		"_cptool_compress() {\n",
+		"'-loop=[number of compress iterations]:<uint>:_cptool_value' \\\n",
+		"'-folder=:<string>:_cptool_value' && ret=0\n",
+		"_cptool_words=(\"${(@)words[1,CURRENT-1]}\")\n",
+		"_cptool_compress_zipfile && ret=0\n",
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("zsh completion should contain %q:\n%v", s, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Fatalf("zsh completion should not contain hidden flag:\n%v", buf.String())
+	}
+}
+
+func TestFishCompletion(t *testing.T) {
+	type fishStruct struct {
+		ConfigFile string `usage:"working profile's name"`
+		Verbose    bool
+		Secret     string `hidden:""`
+		Compress   struct {
+			Loop    uint `usage:"number of compress iterations"`
+			ZipFile struct {
+				FileName string `alias:"f"`
+			} `usage:"zip a file" action:""`
+		} `usage:"to compress things" action:""`
+	}
+	filler := myflags.NewFiller("cptool", "a zip command")
+	if err := filler.Fill(&fishStruct{}); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := filler.GenFishCompletion(buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`complete -c 'cptool' -n 'not __fish_seen_subcommand_from compress help' -o 'configfile' -r -f -a '(__cptool_complete_value)' -d 'working profile\'s name'` + "\n",
+		`complete -c 'cptool' -n 'not __fish_seen_subcommand_from compress help' -o 'verbose' -f` + "\n",
+		`complete -c 'cptool' -n 'not __fish_seen_subcommand_from compress help' -f -a 'compress' -d 'to compress things'` + "\n",
+		`complete -c 'cptool' -n '__fish_seen_subcommand_from help; and not __fish_seen_subcommand_from compress' -f -a 'compress'` + "\n",
+		`complete -c 'cptool' -n '__fish_seen_subcommand_from compress; and not __fish_seen_subcommand_from zipfile' -o 'loop' -r -f -a '(__cptool_complete_value)' -d 'number of compress iterations'` + "\n",
+		`complete -c 'cptool' -n '__fish_seen_subcommand_from compress; and __fish_seen_subcommand_from zipfile' -o 'f' -r -f -a '(__cptool_complete_value)'` + "\n",
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("fish completion should contain %q:\n%v", s, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Fatalf("fish completion should not contain hidden flag:\n%v", buf.String())
+	}
+}
+
+func TestPowerShellCompletion(t *testing.T) {
+	type psStruct struct {
+		ConfigFile string `usage:"working profile's name"`
+		Verbose    bool
+		Secret     string `hidden:""`
+		Compress   struct {
+			Loop    uint `usage:"number of compress iterations"`
+			ZipFile struct {
+				FileName string `alias:"f"`
+			} `usage:"zip a file" action:""`
+		} `usage:"to compress things" action:""`
+	}
+	filler := myflags.NewFiller("cptool", "a zip command")
+	if err := filler.Fill(&psStruct{}); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := filler.GenPowerShellCompletion(buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"Register-ArgumentCompleter -Native -CommandName 'cptool' -ScriptBlock {\n",
+		`        '' = @{
+            Flags = @(
+                @{ Name = 'configfile'; Usage = 'working profile''s name' }
+                @{ Name = 'verbose'; Usage = '' }
+            )
+            ValueFlags = @('configfile', 'secret')
+            Actions = @(
+                @{ Name = 'compress'; Usage = 'to compress things' }
+            )
+            Aliases = @{}
+        }
+        'compress' = @{
+            Flags = @(
+                @{ Name = 'loop'; Usage = 'number of compress iterations' }
+            )
+            ValueFlags = @('loop')
+            Actions = @(
+                @{ Name = 'zipfile'; Usage = 'zip a file' }
+            )
+            Aliases = @{}
+        }
+        'compress zipfile' = @{
+            Flags = @(
+                @{ Name = 'f'; Usage = '' }
+            )
+            ValueFlags = @('f')
+            Actions = @()
+            Aliases = @{}
+        }
+`,
+		"    $helpAction = 'help'\n",
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("powershell completion should contain %q:\n%v", s, buf.String())
+		}
+	}
+	out2 := new(bytes.Buffer)
+	filler.GenPowerShellCompletion(out2)
+	if buf.String() != out2.String() {
+		t.Fatal("powershell completion should be deterministic")
+	}
+}
+
+type completionProfile struct {
+	name string
+}
+
+func (p completionProfile) MarshalText() ([]byte, error) {
+	return []byte(p.name), nil
+}
+
+func (p *completionProfile) UnmarshalText(text []byte) error {
+	p.name = string(text)
+	return nil
+}
+
+func (p *completionProfile) Complete(toComplete string) []string {
+	return []string{"prod", "preprod", "dev"}
+}
+
+func TestCompleteAction(t *testing.T) {
+	type completeStruct struct {
+		Mode     string `complete:"fast,slow"`
+		Verbose  bool
+		Secret   string `hidden:""`
+		Config   string `path:"file"`
+		Timeout  time.Duration
+		Addr     netip.Addr
+		Compress struct {
+			Loop     uint
+			Profile  completionProfile
+			Profiles []*completionProfile
+			ZipFile  struct {
+				FileName string `alias:"f"`
+			} `action:""`
+		} `action:""`
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"a.conf", "b.conf"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "adir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	caseList := []struct {
+		args     []string
+		expected []string
+	}{
+		{args: []string{""}, expected: []string{"compress", "help"}},
+		{args: []string{"-"}, expected: []string{"-mode", "-verbose", "-config", "-timeout", "-addr"}},
+		{args: []string{"-verbose=t"}, expected: []string{"-verbose=true"}},
+		{args: []string{"-timeout", "10"}, expected: []string{"10ns", "10us", "10ms", "10s", "10m", "10h"}},
+		{args: []string{"-timeout", "10s"}, expected: []string{}},
+		{args: []string{"--v"}, expected: []string{"--verbose"}},
+		{args: []string{"-mode", ""}, expected: []string{"fast", "slow"}},
+		{args: []string{"-mode=s"}, expected: []string{"-mode=slow"}},
+		{args: []string{"-verbose", "c"}, expected: []string{"compress"}},
+		{args: []string{"-secret", "x", "compress", "-"}, expected: []string{"-loop", "-profile", "-profiles"}},
+		{args: []string{"compress", "-loop", ""}, expected: []string{"1", "10", "2"}},
+		{args: []string{"compress", "-profile", "pr"}, expected: []string{"prod", "preprod"}},
+		{args: []string{"compress", "-profiles", "dev,pr"}, expected: []string{"dev,prod", "dev,preprod"}},
+		{args: []string{"compress", "-loop", "1", ""}, expected: []string{"zipfile"}},
+		{args: []string{"compress", "zipfile", ""}, expected: []string{"-f"}},
+		{args: []string{"help", "compress", ""}, expected: []string{"zipfile"}},
+		{args: []string{"-config", dir + "/a"}, expected: []string{dir + "/a.conf", dir + "/adir/"}},
+		{args: []string{"unknown", ""}, expected: []string{}},
+	}
+	for i, c := range caseList {
+		in := new(completeStruct)
+		buf := new(bytes.Buffer)
+		filler := myflags.NewFiller("cptool", "a zip command", myflags.WithCompletionWriter(buf),
+			myflags.WithFlagErrHandling(flag.ContinueOnError),
+			myflags.WithCompletionFunc(&in.Compress.Loop, func(string) []string {
+				return []string{"1", "10", "2"}
+			}))
+		if err := filler.Fill(in); err != nil {
+			t.Fatal(err)
+		}
+		_, err := filler.ParseArgs(append([]string{myflags.CompleteActionName}, c.args...))
+		if !errors.Is(err, myflags.ErrCompletion) {
+			t.Fatalf("case %d: expected ErrCompletion, got %v", i, err)
+		}
+		got := strings.Fields(buf.String())
+		if !slices.Equal(got, c.expected) {
+			t.Fatalf("case %d: expected %v, got %v", i, c.expected, got)
+		}
+	}
+}
+
+func TestCompleteLocalAddr(t *testing.T) {
+	type addrStruct struct {
+		Addr netip.Addr
+	}
+	buf := new(bytes.Buffer)
+	filler := myflags.NewFiller("test", "addr test", myflags.WithCompletionWriter(buf),
+		myflags.WithFlagErrHandling(flag.ContinueOnError))
+	if err := filler.Fill(new(addrStruct)); err != nil {
+		t.Fatal(err)
+	}
+	filler.ParseArgs([]string{myflags.CompleteActionName, "-addr", ""})
+	for _, s := range strings.Fields(buf.String()) {
+		if _, err := netip.ParseAddr(s); err != nil {
+			t.Fatalf("candidate %v is not an address, %v", s, err)
+		}
+	}
+}
+
+func TestCompleteTag(t *testing.T) {
+	type completeTagStruct struct {
+		Mode  string   `complete:"fast,slow"`
+		Modes []string `complete:"fast, slow"`
+	}
+	//complete tag only supplies completion candidates, other values are accepted
+	in := new(completeTagStruct)
+	filler := myflags.NewFiller("test", "complete tag test", myflags.WithFlagErrHandling(flag.ContinueOnError))
+	if err := filler.Fill(in); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := filler.ParseArgs([]string{"-mode", "medium", "-modes", "fast,medium"}); err != nil {
+		t.Fatal(err)
+	}
+	if in.Mode != "medium" || !slices.Equal(in.Modes, []string{"fast", "medium"}) {
+		t.Fatalf("unexpected result %+v", in)
+	}
+	buf := new(bytes.Buffer)
+	filler = myflags.NewFiller("test", "complete tag test", myflags.WithFlagErrHandling(flag.ContinueOnError),
+		myflags.WithCompletionWriter(buf))
+	if err := filler.Fill(new(completeTagStruct)); err != nil {
+		t.Fatal(err)
+	}
+	filler.ParseArgs([]string{myflags.CompleteActionName, "-modes", "fast,"})
+	if got := strings.Fields(buf.String()); !slices.Equal(got, []string{"fast,fast", "fast,slow"}) {
+		t.Fatalf("unexpected completion %v", got)
+	}
+	if err := myflags.NewFiller("test", "").Fill(&struct {
+		Mode string `complete:","`
+	}{}); err == nil {
+		t.Fatal("complete tag without candidate should fail")
+	}
+}
+
+type ExecRoot struct {
+	Verbose  bool
+	Compress ExecCompress `action:""`
+	Extract  struct {
+		InputFile string
+	} `action:""`
+	calls []string
+}
+
+func (r *ExecRoot) Run(ctx context.Context) error {
+	r.calls = append(r.calls, "root")
+	return nil
+}
+
+type ExecCompress struct {
+	Loop    uint
+	ZipFile ExecZipFile `action:""`
+}
+
+type ExecZipFile struct {
+	FileName string `alias:"f"`
+}
+
+func (z *ExecZipFile) Run(ctx context.Context) error {
+	root, ok := myflags.StructFromContext[*ExecRoot](ctx)
+	if !ok {
+		return errors.New("root not found")
+	}
+	compress, ok := myflags.StructFromContext[*ExecCompress](ctx)
+	if !ok {
+		return errors.New("compress not found")
+	}
+	e, _ := myflags.ExecutionFromContext(ctx)
+	root.calls = append(root.calls, fmt.Sprintf("zipfile %v %v %v %v", root.Verbose, compress.Loop, z.FileName, e.Actions))
+	return nil
+}
+
+func TestExecute(t *testing.T) {
+	caseList := []struct {
+		args     []string
+		mode     myflags.RunMode
+		expected []string
+		err      error
+	}{
+		{args: []string{"-verbose", "compress", "-loop", "3", "zipfile", "-f", "a.txt"},
+			expected: []string{"zipfile true 3 a.txt [Compress ZipFile]"}},
+		{args: []string{"compress", "zipfile"}, mode: myflags.RunEachLevel,
+			expected: []string{"root", "zipfile false 0  [Compress ZipFile]"}},
+		{args: []string{}, expected: []string{"root"}},
+		{args: []string{"compress"}, err: myflags.ErrNoHandler},
+		{args: []string{"extract"}, mode: myflags.RunEachLevel, err: myflags.ErrNoHandler},
+	}
+	for i, c := range caseList {
+		root := new(ExecRoot)
+		filler := myflags.NewFiller("cptool", "a zip command", myflags.WithRunMode(c.mode))
+		if err := filler.Fill(root); err != nil {
+			t.Fatal(err)
+		}
+		err := filler.ExecuteArgs(context.Background(), c.args)
+		if c.err != nil {
+			if !errors.Is(err, c.err) {
+				t.Fatalf("case %d: expected error %v, got %v", i, c.err, err)
+			}
+			if len(root.calls) != 0 {
+				t.Fatalf("case %d: no handler should be called, got %v", i, root.calls)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if !slices.Equal(root.calls, c.expected) {
+			t.Fatalf("case %d: expected %v, got %v", i, c.expected, root.calls)
+		}
+	}
+	filler := myflags.NewFiller("cptool", "a zip command")
+	filler.Fill(new(ExecRoot))
+	err := filler.ExecuteArgs(context.Background(), []string{"compress"})
+	if err == nil || err.Error() != "no handler: cptool compress, available actions: zipfile" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+type hookLog struct {
+	calls     []string
+	failLevel string //the level whose Before fails
+	runErr    error
+}
+
+func (l *hookLog) before(level string) error {
+	l.calls = append(l.calls, "before "+level)
+	if l.failLevel == level {
+		return fmt.Errorf("%v before failed", level)
+	}
+	return nil
+}
+
+func (l *hookLog) after(level string, err error) error {
+	l.calls = append(l.calls, fmt.Sprintf("after %v %v", level, err))
+	if level == "root" && err != nil {
+		return errors.New("root after failed")
+	}
+	return nil
+}
+
+type HookRoot struct {
+	Compress HookCompress `action:""`
+	log      *hookLog
+}
+
+func (r *HookRoot) Before(ctx context.Context) error {
+	return r.log.before("root")
+}
+
+func (r *HookRoot) After(ctx context.Context, err error) error {
+	return r.log.after("root", err)
+}
+
+type HookCompress struct {
+	ZipFile HookZipFile `action:""`
+}
+
+func (c *HookCompress) Before(ctx context.Context) error {
+	root, _ := myflags.StructFromContext[*HookRoot](ctx)
+	return root.log.before("compress")
+}
+
+type HookZipFile struct{}
+
+func (z *HookZipFile) Before(ctx context.Context) error {
+	root, _ := myflags.StructFromContext[*HookRoot](ctx)
+	return root.log.before("zipfile")
+}
+
+func (z *HookZipFile) After(ctx context.Context, err error) error {
+	root, _ := myflags.StructFromContext[*HookRoot](ctx)
+	return root.log.after("zipfile", err)
+}
+
+func (z *HookZipFile) Run(ctx context.Context) error {
+	root, _ := myflags.StructFromContext[*HookRoot](ctx)
+	root.log.calls = append(root.log.calls, "run")
+	return root.log.runErr
+}
+
+func TestHooks(t *testing.T) {
+	runErr := errors.New("run failed")
+	caseList := []struct {
+		log         *hookLog
+		expected    []string
+		expectedErr []string
+	}{
+		{
+			log: &hookLog{},
+			expected: []string{"before root", "before compress", "before zipfile", "run",
+				"after zipfile <nil>", "after root <nil>"},
+		},
+		{
+			log: &hookLog{runErr: runErr},
+			expected: []string{"before root", "before compress", "before zipfile", "run",
+				"after zipfile run failed", "after root run failed"},
+			expectedErr: []string{"run failed", "root after failed"},
+		},
+		{
+			log: &hookLog{failLevel: "compress"},
+			expected: []string{"before root", "before compress",
+				"after root compress before failed"},
+			expectedErr: []string{"compress before failed", "root after failed"},
+		},
+		{
+			log:         &hookLog{failLevel: "root"},
+			expected:    []string{"before root"},
+			expectedErr: []string{"root before failed"},
+		},
+	}
+	for i, c := range caseList {
+		root := &HookRoot{log: c.log}
+		filler := myflags.NewFiller("cptool", "a zip command")
+		if err := filler.Fill(root); err != nil {
+			t.Fatal(err)
+		}
+		err := filler.ExecuteArgs(context.Background(), []string{"compress", "zipfile"})
+		if !slices.Equal(c.log.calls, c.expected) {
+			t.Fatalf("case %d: expected calls %v, got %v", i, c.expected, c.log.calls)
+		}
+		if len(c.expectedErr) == 0 {
+			if err != nil {
+				t.Fatalf("case %d: unexpected error %v", i, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != strings.Join(c.expectedErr, "\n") {
+			t.Fatalf("case %d: expected error %q, got %v", i, strings.Join(c.expectedErr, "\n"), err)
+		}
+	}
+	root := &HookRoot{log: &hookLog{runErr: runErr}}
+	filler := myflags.NewFiller("cptool", "a zip command")
+	filler.Fill(root)
+	if err := filler.ExecuteArgs(context.Background(), []string{"compress", "zipfile"}); !errors.Is(err, runErr) {
+		t.Fatalf("error should wrap the run error, got %v", err)
+	}
+}
+
+func TestActionAliases(t *testing.T) {
+	type aliasStruct struct {
+		Compress struct {
+			Loop    uint
+			ZipFile struct {
+				FileName string `alias:"f"`
+			} `usage:"zip a file" action:"" aliases:"zf"`
+		} `usage:"to compress things" action:"" aliases:"c, comp"`
+		Extract struct{} `usage:"to unzip things" action:"" aliases:"x"`
+	}
+	caseList := []struct {
+		args     []string
+		expected []string
+	}{
+		{args: []string{"c", "-loop", "1", "zf", "-f", "a"}, expected: []string{"Compress", "ZipFile"}},
+		{args: []string{"comp", "zipfile"}, expected: []string{"Compress", "ZipFile"}},
+		{args: []string{"x"}, expected: []string{"Extract"}},
+	}
+	for i, c := range caseList {
+		filler := myflags.NewFiller("cptool", "a zip command")
+		if err := filler.Fill(new(aliasStruct)); err != nil {
+			t.Fatal(err)
+		}
+		acts, err := filler.ParseArgs(c.args)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if !slices.Equal(acts, c.expected) {
+			t.Fatalf("case %d: expected %v, got %v", i, c.expected, acts)
+		}
+	}
+
+	filler := myflags.NewFiller("cptool", "a zip command", myflags.WithUsageWidth(80))
+	if err := filler.Fill(new(aliasStruct)); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"  = compress (c, comp): to compress things\n", "    = zipfile (zf): zip a file\n", "  = extract (x): to unzip things\n"} {
+		if !strings.Contains(filler.UsageStr(""), s) {
+			t.Fatalf("usage should contain %q:\n%v", s, filler.UsageStr(""))
+		}
+	}
+	if !strings.Contains(filler.HelpStr(), "  = compress (c, comp): to compress things\n") {
+		t.Fatalf("help should contain aliases:\n%v", filler.HelpStr())
+	}
+	buf := new(bytes.Buffer)
+	filler = myflags.NewFiller("cptool", "a zip command", myflags.WithCompletionWriter(buf),
+		myflags.WithFlagErrHandling(flag.ContinueOnError))
+	filler.Fill(new(aliasStruct))
+	filler.ParseArgs([]string{myflags.CompleteActionName, "c", ""})
+	if buf.String() != "zipfile\n" {
+		t.Fatalf("unexpected completion %q", buf.String())
+	}
+	buf.Reset()
+	filler.GenBashCompletion(buf)
+	if !strings.Contains(buf.String(), "        aliases='c=compress comp=compress x=extract'\n") {
+		t.Fatalf("bash completion should contain aliases:\n%v", buf.String())
+	}
+
+	type collisionStruct struct {
+		Compress struct{} `action:"" aliases:"x"`
+		Extract  struct{} `action:"" aliases:"x"`
+	}
+	if err := myflags.NewFiller("cptool", "").Fill(new(collisionStruct)); err == nil {
+		t.Fatal("alias collision should fail")
+	}
+	type nameCollisionStruct struct {
+		Compress struct{} `action:"" aliases:"extract"`
+		Extract  struct{} `action:""`
+	}
+	if err := myflags.NewFiller("cptool", "").Fill(new(nameCollisionStruct)); err == nil {
+		t.Fatal("alias collision with action name should fail")
+	}
+	type reversedCollisionStruct struct {
+		Extract  struct{} `action:""`
+		Compress struct{} `action:"" aliases:"extract"`
+	}
+	if err := myflags.NewFiller("cptool", "").Fill(new(reversedCollisionStruct)); err == nil {
+		t.Fatal("alias collision with an earlier action name should fail")
+	}
+	//an action or alias named help replaces the built-in help action
+	type helpAliasStruct struct {
+		Compress struct{} `action:"" aliases:"help"`
+	}
+	type helpActionStruct struct {
+		Help struct{} `action:""`
+	}
+	for i, in := range []any{new(helpAliasStruct), new(helpActionStruct)} {
+		filler = myflags.NewFiller("cptool", "", myflags.WithFlagErrHandling(flag.ContinueOnError))
+		if err := filler.Fill(in); err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		acts, err := filler.ParseArgs([]string{"help"})
+		if err != nil || len(acts) != 1 {
+			t.Fatalf("case %d: help should be parsed as the action, got %v, %v", i, acts, err)
+		}
+	}
+	type completeActionStruct struct {
+		Complete struct{} `action:"" alias:"__complete"`
+	}
+	if err := myflags.NewFiller("cptool", "").Fill(new(completeActionStruct)); err == nil {
+		t.Fatal("action colliding with the built-in completion action should fail")
+	}
+	type completeAliasStruct struct {
+		Compress struct {
+			ZipFile struct{} `action:"" aliases:"__complete"`
+		} `action:""`
+		Extract struct{} `action:"" aliases:"__complete"`
+	}
+	if err := myflags.NewFiller("cptool", "").Fill(new(completeAliasStruct)); err == nil {
+		t.Fatal("alias colliding with the built-in completion action should fail")
+	}
+}
+
+func TestDefaultAction(t *testing.T) {
+	type defaultStruct struct {
+		Compress struct {
+			Loop    uint
+			ZipFile struct {
+				FileName string
+			} `usage:"zip a file" action:"" default:""`
+			ZipFolder struct{} `usage:"zip a folder" action:""`
+		} `usage:"to compress things" action:"" actionrequired:""`
+		Extract struct {
+			Folder struct{} `action:""`
+		} `usage:"to unzip things" action:"" default:""`
+	}
+	caseList := []struct {
+		args     []string
+		required bool
+		expected []string
+	}{
+		{args: []string{}, expected: []string{"Extract"}},
+		{args: []string{"compress", "-loop", "1"}, expected: []string{"Compress", "ZipFile"}},
+		{args: []string{"compress", "zipfolder"}, expected: []string{"Compress", "ZipFolder"}},
+		{args: []string{"extract"}, expected: []string{"Extract"}},
+		{args: []string{"compress"}, required: true, expected: []string{"Compress", "ZipFile"}},
+		{args: []string{"compress", "zipfile"}, required: true, expected: []string{"Compress", "ZipFile"}},
+	}
+	for i, c := range caseList {
+		filler := myflags.NewFiller("cptool", "a zip command", myflags.WithActionRequired(c.required))
+		if err := filler.Fill(new(defaultStruct)); err != nil {
+			t.Fatal(err)
+		}
+		acts, err := filler.ParseArgs(c.args)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if !slices.Equal(acts, c.expected) {
+			t.Fatalf("case %d: expected %v, got %v", i, c.expected, acts)
+		}
+	}
+
+	type requiredStruct struct {
+		Compress struct {
+			ZipFile   struct{} `action:""`
+			ZipFolder struct{} `action:""`
+		} `action:"" actionrequired:""`
+		Extract struct {
+			Folder struct{} `action:""`
+		} `action:""`
+	}
+	filler := myflags.NewFiller("cptool", "", myflags.WithFlagErrHandling(flag.ContinueOnError))
+	if err := filler.Fill(new(requiredStruct)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := filler.ParseArgs([]string{"compress"})
+	if !errors.Is(err, myflags.ErrActionRequired) {
+		t.Fatalf("expected ErrActionRequired, got %v", err)
+	}
+	if err.Error() != "action required: cptool compress, choose one of zipfile, zipfolder" {
+		t.Fatalf("unexpected error message %v", err)
+	}
+	filler = myflags.NewFiller("cptool", "")
+	filler.Fill(new(requiredStruct))
+	if _, err = filler.ParseArgs([]string{"extract"}); err != nil {
+		t.Fatalf("extract doesn't require an action, got %v", err)
+	}
+	filler = myflags.NewFiller("cptool", "", myflags.WithFlagErrHandling(flag.ContinueOnError), myflags.WithActionRequired(true))
+	filler.Fill(new(requiredStruct))
+	_, err = filler.ParseArgs([]string{})
+	if err == nil || err.Error() != "action required: cptool, choose one of compress, extract" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	type allHiddenStruct struct {
+		Maintain struct {
+			Repair struct{} `action:"" hidden:""`
+			Reset  struct{} `action:"" hidden:""`
+		} `action:"" actionrequired:""`
+	}
+	filler = myflags.NewFiller("cptool", "", myflags.WithFlagErrHandling(flag.ContinueOnError))
+	filler.Fill(new(allHiddenStruct))
+	_, err = filler.ParseArgs([]string{"maintain"})
+	if err == nil || err.Error() != "action required: cptool maintain, choose one of repair, reset" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	filler = myflags.NewFiller("cptool", "a zip command", myflags.WithUsageWidth(80))
+	if err := filler.Fill(new(defaultStruct)); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"    = zipfile [default]: zip a file\n", "  = extract [default]: to unzip things\n"} {
+		if !strings.Contains(filler.UsageStr(""), s) {
+			t.Fatalf("usage should contain %q:\n%v", s, filler.UsageStr(""))
+		}
+	}
+
+	type multiDefaultStruct struct {
+		Compress struct{} `action:"" default:""`
+		Extract  struct{} `action:"" default:""`
+	}
+	if err := myflags.NewFiller("cptool", "").Fill(new(multiDefaultStruct)); err == nil {
+		t.Fatal("multiple default actions should fail")
+	}
+}
@@ -1,7 +1,9 @@
 package myflags
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 )
@@ -24,6 +26,11 @@ func (s *strType) FromStr(input string, tag reflect.StructTag) (any, error) {
 	return input, nil
 }
 
+// Format implements FormatDescriber interface
+func (s *strType) Format(tag reflect.StructTag) string {
+	return "string"
+}
+
 type boolType bool
 
 func (b *boolType) ToStr(in any, tag reflect.StructTag) string {
@@ -33,6 +40,16 @@ func (b *boolType) FromStr(input string, tag reflect.StructTag) (any, error) {
 	return strconv.ParseBool(input)
 }
 
+// Format implements FormatDescriber interface
+func (b *boolType) Format(tag reflect.StructTag) string {
+	return "bool"
+}
+
+// CompleteValue implements ValueCompleter interface
+func (b *boolType) CompleteValue(toComplete string, tag reflect.StructTag) []string {
+	return []string{"true", "false"}
+}
+
 type floatType struct {
 	len int
 }
@@ -40,10 +57,24 @@ type floatType struct {
 func (f *floatType) ToStr(in any, tag reflect.StructTag) string {
 	return fmt.Sprint(in)
 }
+
 func (f *floatType) FromStr(s string, tag reflect.StructTag) (any, error) {
-	f64, err := strconv.ParseFloat(s, f.len)
+	f64, err := strconv.ParseFloat(removeDigitSep(s, tag), f.len)
 	if err != nil {
-		return 0, nil
+		if !isStrictConversion(tag) {
+			if f.len == 32 {
+				return float32(0), nil
+			}
+			return float64(0), nil
+		}
+		if errors.Is(err, strconv.ErrRange) {
+			max := math.MaxFloat64
+			if f.len == 32 {
+				max = math.MaxFloat32
+			}
+			return nil, fmt.Errorf("%v is out of range of float%d [%v, %v], %w", s, f.len, -max, max, strconv.ErrRange)
+		}
+		return nil, err
 	}
 	switch f.len {
 	case 32:
@@ -52,3 +83,8 @@ func (f *floatType) FromStr(s string, tag reflect.StructTag) (any, error) {
 	}
 	return f64, nil
 }
+
+// Format implements FormatDescriber interface
+func (f *floatType) Format(tag reflect.StructTag) string {
+	return fmt.Sprintf("float%d", f.len)
+}
@@ -0,0 +1,193 @@
+package myflags
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// PathTag is the struct field tag used to specify constraints of a filesystem path field,
+// it is a list of following keywords separated by ",":
+//   - file: the path must be a regular file if it exists
+//   - dir: the path must be a directory if it exists
+//   - exists: the path must exist
+//   - notexists: the path must not exist
+//   - readable: the path must be readable if it exists
+//   - writable: the path must be writable if it exists, otherwise its parent directory must be writable
+//
+// only string field and field implements encoding.TextMarshaler/encoding.TextUnmarshaler are supported
+const PathTag = "path"
+
+var ErrInvalidPath = errors.New("invalid path")
+
+// WithPathExpansion returns a FillerOption that specifies whether to expand leading "~" and
+// environment variables in the value of fields with path tag, the expanded value is written back to the field
+func WithPathExpansion(enabled bool) FillerOption {
+	return func(filler *Filler) {
+		filler.expandPath = enabled
+	}
+}
+
+type pathRule struct {
+	isFile    bool
+	isDir     bool
+	exists    bool
+	notExists bool
+	readable  bool
+	writable  bool
+}
+
+func parsePathRule(val string) (*pathRule, error) {
+	r := new(pathRule)
+	for _, kw := range strings.Split(val, ",") {
+		switch strings.TrimSpace(kw) {
+		case "":
+		case "file":
+			r.isFile = true
+		case "dir":
+			r.isDir = true
+		case "exists":
+			r.exists = true
+		case "notexists":
+			r.notExists = true
+		case "readable":
+			r.readable = true
+		case "writable":
+			r.writable = true
+		default:
+			return nil, fmt.Errorf("unknown path constraint %v", kw)
+		}
+	}
+	if r.isFile && r.isDir {
+		return nil, fmt.Errorf("path constraints file and dir can't be used together")
+	}
+	if r.exists && r.notExists {
+		return nil, fmt.Errorf("path constraints exists and notexists can't be used together")
+	}
+	return r, nil
+}
+
+// resolvePathRule parses the path tag of info
+func (info *flagInfo) resolvePathRule() error {
+	val, ok := info.tag.Lookup(PathTag)
+	if !ok {
+		return nil
+	}
+	if info.ref.Elem().Kind() != reflect.String && !info.ref.Type().Implements(textEncodingInt) {
+		return fmt.Errorf("flag -%v: path tag is only supported for string or encoding.TextUnmarshaler field, not %v",
+			info.name, info.ref.Type().Elem())
+	}
+	var err error
+	info.pathRule, err = parsePathRule(val)
+	if err != nil {
+		return fmt.Errorf("flag -%v: %w", info.name, err)
+	}
+	return nil
+}
+
+func (info *flagInfo) getPathValue() (string, error) {
+	if info.ref.Type().Implements(textEncodingInt) {
+		buf, err := info.ref.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(buf), err
+	}
+	return info.ref.Elem().String(), nil
+}
+
+func (info *flagInfo) setPathValue(s string) error {
+	if info.ref.Type().Implements(textEncodingInt) {
+		return info.ref.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	info.ref.Elem().SetString(s)
+	return nil
+}
+
+// expandPath expands leading "~" and environment variables in p
+func expandPath(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, "~"+string(filepath.Separator)) {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = home + p[1:]
+		}
+	}
+	return os.ExpandEnv(p)
+}
+
+// check checks if p meets the rule, p must be an absolute path
+func (rule *pathRule) check(p string) error {
+	fi, err := os.Stat(p)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to access %v, %w", p, err)
+		}
+		if rule.exists {
+			return fmt.Errorf("%v doesn't exist, %w", p, err)
+		}
+		if rule.writable {
+			if err = checkWritable(filepath.Dir(p)); err != nil {
+				return fmt.Errorf("%v can't be created, %w", p, err)
+			}
+		}
+		return nil
+	}
+	if rule.notExists {
+		return fmt.Errorf("%v already exists, %w", p, os.ErrExist)
+	}
+	if rule.isFile && !fi.Mode().IsRegular() {
+		return fmt.Errorf("%v is not a regular file", p)
+	}
+	if rule.isDir && !fi.IsDir() {
+		return fmt.Errorf("%v is not a directory", p)
+	}
+	if rule.readable {
+		f, err := os.Open(p)
+		if err != nil {
+			return fmt.Errorf("%v is not readable, %w", p, err)
+		}
+		f.Close()
+	}
+	if rule.writable {
+		if err = checkWritable(p); err != nil {
+			return fmt.Errorf("%v is not writable, %w", p, err)
+		}
+	}
+	return nil
+}
+
+// checkPaths expands and validates the value of path fields in the parsed fillers,
+// including the fields not specified in the command line
+func checkPaths(parsedFillers []*Filler) error {
+	for _, filler := range parsedFillers {
+		for _, info := range filler.flags {
+			if info.pathRule == nil {
+				continue
+			}
+			p, err := info.getPathValue()
+			if err != nil {
+				return fmt.Errorf("%w: -%v, %w", ErrInvalidPath, info.name, err)
+			}
+			if filler.expandPath {
+				expanded := expandPath(p)
+				if expanded != p {
+					p = expanded
+					if err = info.setPathValue(p); err != nil {
+						return fmt.Errorf("%w: -%v, %w", ErrInvalidPath, info.name, err)
+					}
+				}
+			}
+			if p == "" {
+				continue
+			}
+			abs, err := filepath.Abs(p)
+			if err != nil {
+				return fmt.Errorf("%w: -%v, %w", ErrInvalidPath, info.name, err)
+			}
+			if err = info.pathRule.check(abs); err != nil {
+				return fmt.Errorf("%w: -%v, %w", ErrInvalidPath, info.name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package myflags
+
+import "os"
+
+// checkWritable checks if p is writable via its permission bits, without writing anything
+func checkWritable(p string) error {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+	if fi.Mode().Perm()&0200 == 0 {
+		return &os.PathError{Op: "access", Path: p, Err: os.ErrPermission}
+	}
+	return nil
+}
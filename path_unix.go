@@ -0,0 +1,13 @@
+//go:build unix
+
+package myflags
+
+import "syscall"
+
+// wOK is the W_OK mode of access(2)
+const wOK = 0x2
+
+// checkWritable checks if p is writable by the current user via access(2), without writing anything
+func checkWritable(p string) error {
+	return syscall.Access(p, wOK)
+}
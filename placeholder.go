@@ -0,0 +1,82 @@
+package myflags
+
+import (
+	"reflect"
+	"strings"
+)
+
+// PlaceholderTag is the struct field tag used to override the value placeholder of the flag shown in help,
+// e.g. `placeholder:"file"` shows "-config <file>"; an empty value means no placeholder
+const PlaceholderTag = "placeholder"
+
+// FormatDescriber could be optionally implemented by a RegisteredConverters, to describe the accepted format
+// of the value shown in the placeholder of help, e.g. "2006-01-02 15:04:05" for time.Time
+type FormatDescriber interface {
+	Format(tag reflect.StructTag) string
+}
+
+// wellKnownFormats are the formats of well known types that are not registered, key is getTypeName of the type
+var wellKnownFormats = map[string]string{
+	"net/netip=>netip.Addr":     "ip",
+	"net/netip=>netip.AddrPort": "ip:port",
+	"net/netip=>netip.Prefix":   "prefix",
+	"net=>net.IP":               "ip",
+	"net=>net.HardwareAddr":     "mac",
+}
+
+// typeFormat returns the format description of type t
+func typeFormat(t reflect.Type, tag reflect.StructTag) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if conv := globalRegistry.GetViaType(t); conv != nil {
+		if fd, ok := conv.(FormatDescriber); ok {
+			return fd.Format(tag)
+		}
+	}
+	if f, ok := wellKnownFormats[getTypeName(t)]; ok {
+		return f
+	}
+	name := t.Name()
+	if name == "" {
+		name = t.String()
+	}
+	return strings.ToLower(name)
+}
+
+// isBool returns true if the flag is a bool flag, which doesn't take a value
+func (info *flagInfo) isBool() bool {
+	if f := info.owner.fs.Lookup(info.name); f != nil {
+		if b, ok := f.Value.(isBoolInt); ok {
+			return b.IsBoolFlag()
+		}
+	}
+	return false
+}
+
+// isList returns true if the flag is a list of values separated by ","
+func (info *flagInfo) isList() bool {
+	if f := info.owner.fs.Lookup(info.name); f != nil {
+		_, ok := f.Value.(*listType)
+		return ok
+	}
+	return false
+}
+
+// placeholder returns the value placeholder of the flag shown in help, e.g. "<uint16, hex>", "<ip,...>",
+// it is "" for bool flag
+func (info *flagInfo) placeholder() string {
+	if p, ok := info.tag.Lookup(PlaceholderTag); ok {
+		if p == "" {
+			return ""
+		}
+		return "<" + p + ">"
+	}
+	if info.isBool() {
+		return ""
+	}
+	if info.isList() {
+		return "<" + typeFormat(info.valueType(), info.tag) + ",...>"
+	}
+	return "<" + typeFormat(info.valueType(), info.tag) + ">"
+}
@@ -0,0 +1,168 @@
+package myflags
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// psQuote returns s single quoted for PowerShell
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// psItems returns a PowerShell array of hashtables with Name and Usage, each in its own line with indent
+func psItems(names, usages []string, indent string) string {
+	if len(names) == 0 {
+		return "@()"
+	}
+	buf := new(strings.Builder)
+	buf.WriteString("@(\n")
+	for i, name := range names {
+		fmt.Fprintf(buf, "%v    @{ Name = %v; Usage = %v }\n", indent, psQuote(name), psQuote(usages[i]))
+	}
+	buf.WriteString(indent + ")")
+	return buf.String()
+}
+
+// powershellTemplate is the body of the PowerShell completion script, %[1]v is the command name,
+// %[2]v is the level table, %[3]v is the name of the built-in help action or empty if there is none,
+// %[4]v is CompleteActionName
+const powershellTemplate = `Register-ArgumentCompleter -Native -CommandName %[1]v -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $levels = @{
+%[2]v    }
+    $helpAction = %[3]v
+    $words = @($commandAst.CommandElements | Where-Object { $_.Extent.EndOffset -lt $cursorPosition } | ForEach-Object { $_.Extent.Text })
+    $path = ''
+    $inArg = $false
+    $help = $false
+    foreach ($word in ($words | Select-Object -Skip 1)) {
+        $level = $levels[$path]
+        if ($inArg) {
+            if (-not $word.StartsWith('-')) {
+                $inArg = $false
+            }
+            continue
+        }
+        if ($word.StartsWith('-')) {
+            if ($word.Contains('=')) {
+                continue
+            }
+            $name = $word
+            if ($name.StartsWith('--')) {
+                $name = $name.Substring(2)
+            } else {
+                $name = $name.Substring(1)
+            }
+            if ($level.ValueFlags -contains $name) {
+                $inArg = $true
+            }
+            continue
+        }
+        if ($helpAction -ne '' -and $path -eq '' -and -not $help -and $word -eq $helpAction) {
+            $help = $true
+            continue
+        }
+        if ($level.Aliases.ContainsKey($word)) {
+            $word = $level.Aliases[$word]
+        }
+        if (-not ($level.Actions | Where-Object { $_.Name -eq $word })) {
+            return
+        }
+        if ($path -eq '') {
+            $path = $word
+        } else {
+            $path = "$path $word"
+        }
+    }
+    if ($inArg -or ($wordToComplete.StartsWith('-') -and $wordToComplete.Contains('='))) {
+        $candidates = & $words[0] %[4]v @($words | Select-Object -Skip 1) $wordToComplete 2>$null
+        foreach ($c in $candidates) {
+            [System.Management.Automation.CompletionResult]::new($c, $c, 'ParameterValue', $c)
+        }
+        return
+    }
+    $level = $levels[$path]
+    $actions = @($level.Actions)
+    if ($path -eq '' -and $helpAction -ne '' -and -not $help) {
+        $actions += @{ Name = $helpAction; Usage = 'show help of an action' }
+    }
+    if ($wordToComplete.StartsWith('-') -or ($wordToComplete -eq '' -and $actions.Count -eq 0)) {
+        if ($help) {
+            return
+        }
+        $prefix = '-'
+        if ($wordToComplete.StartsWith('--')) {
+            $prefix = '--'
+        }
+        foreach ($f in $level.Flags) {
+            $text = $prefix + $f.Name
+            if ($text.StartsWith($wordToComplete, [System.StringComparison]::Ordinal)) {
+                $tooltip = $f.Usage
+                if ($tooltip -eq '') {
+                    $tooltip = $text
+                }
+                [System.Management.Automation.CompletionResult]::new($text, $text, 'ParameterName', $tooltip)
+            }
+        }
+        return
+    }
+    foreach ($a in $actions) {
+        if ($a.Name.StartsWith($wordToComplete, [System.StringComparison]::Ordinal)) {
+            $tooltip = $a.Usage
+            if ($tooltip -eq '') {
+                $tooltip = $a.Name
+            }
+            [System.Management.Automation.CompletionResult]::new($a.Name, $a.Name, 'ParameterValue', $tooltip)
+        }
+    }
+}
+`
+
+// GenPowerShellCompletion writes a PowerShell completion script of the filler to w, which registers
+// an argument completer for the command, completing action names and flags valid at the current action level
+// with their usage as tooltips, flag values are completed by calling the command with CompleteActionName;
+// hidden and deprecated flags and hidden actions are not completed.
+// The output is deterministic, it could be loaded in PowerShell via "cptool completion | Out-String | Invoke-Expression".
+func (filler *Filler) GenPowerShellCompletion(w io.Writer) error {
+	name := filler.fs.Name()
+	levels := new(strings.Builder)
+	for _, level := range filler.completionLevels() {
+		flags, flagUsages := []string{}, []string{}
+		for _, info := range level.flags {
+			flags = append(flags, info.name)
+			flagUsages = append(flagUsages, info.usageLine())
+		}
+		actUsages := []string{}
+		for _, act := range level.actions {
+			actUsages = append(actUsages, level.actionUsageLine(act))
+		}
+		valueFlags := []string{}
+		for _, v := range level.valueFlags {
+			valueFlags = append(valueFlags, psQuote(v))
+		}
+		fmt.Fprintf(levels, "        %v = @{\n", psQuote(strings.Join(level.path, " ")))
+		fmt.Fprintf(levels, "            Flags = %v\n", psItems(flags, flagUsages, "            "))
+		fmt.Fprintf(levels, "            ValueFlags = @(%v)\n", strings.Join(valueFlags, ", "))
+		fmt.Fprintf(levels, "            Actions = %v\n", psItems(level.actions, actUsages, "            "))
+		aliases := []string{}
+		for _, act := range level.actions {
+			for _, alias := range level.actionAliases(act) {
+				aliases = append(aliases, psQuote(alias)+" = "+psQuote(act))
+			}
+		}
+		fmt.Fprintf(levels, "            Aliases = @{%v}\n", strings.Join(aliases, "; "))
+		fmt.Fprintln(levels, "        }")
+	}
+	helpAction := ""
+	if filler.hasHelpAction() {
+		helpAction = HelpActionName
+	}
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "# powershell completion for %v, generated by myflags\n\n", name)
+	fmt.Fprintf(buf, powershellTemplate, psQuote(name), levels.String(), psQuote(helpAction), CompleteActionName)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
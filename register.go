@@ -10,6 +10,12 @@ type RegisteredConverters interface {
 	FromStr(string, reflect.StructTag) (any, error)
 }
 
+// ValueCompleter could be optionally implemented by a RegisteredConverters, to provide completion candidates
+// of the value, toComplete is the partial value being completed, tag is the struct field tag
+type ValueCompleter interface {
+	CompleteValue(toComplete string, tag reflect.StructTag) []string
+}
+
 type registry struct {
 	list map[string]RegisteredConverters
 }
@@ -0,0 +1,85 @@
+package myflags
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	//RequiresTag is the struct field tag used to specify a list of flags that must be set together with the field,
+	//separated by ","
+	RequiresTag = "requires"
+	//ConflictsTag is the struct field tag used to specify a list of flags that can't be set together with the field,
+	//separated by ","
+	ConflictsTag = "conflicts"
+)
+
+var (
+	ErrMissingRequiredFlag = errors.New("missing required flag")
+	ErrConflictingFlags    = errors.New("conflicting flags")
+)
+
+// lookupFlagInfo returns the flag with the specified name in filler or its ancestors, nil if not found
+func (filler *Filler) lookupFlagInfo(name string) *flagInfo {
+	for f := filler; f != nil; f = f.parent {
+		if info, ok := f.flagMap[name]; ok {
+			return info
+		}
+	}
+	return nil
+}
+
+// resolveFlagRefs returns the flags referenced by the tag of info
+func (filler *Filler) resolveFlagRefs(info *flagInfo, tagName string) ([]*flagInfo, error) {
+	val, _ := info.tag.Lookup(tagName)
+	r := []*flagInfo{}
+	for _, name := range strings.Split(val, ",") {
+		name = strings.TrimLeft(strings.TrimSpace(name), "-")
+		if name == "" {
+			continue
+		}
+		ref := filler.lookupFlagInfo(name)
+		if ref == nil {
+			return nil, fmt.Errorf("flag -%v: %v tag references unknown flag %v", info.name, tagName, name)
+		}
+		if ref == info {
+			return nil, fmt.Errorf("flag -%v: %v tag references itself", info.name, tagName)
+		}
+		r = append(r, ref)
+	}
+	return r, nil
+}
+
+// resolveRules resolves the requires and conflicts tags of info
+func (filler *Filler) resolveRules(info *flagInfo) error {
+	var err error
+	info.requires, err = filler.resolveFlagRefs(info, RequiresTag)
+	if err != nil {
+		return err
+	}
+	info.conflicts, err = filler.resolveFlagRefs(info, ConflictsTag)
+	return err
+}
+
+// checkRules checks requires and conflicts rules of the flags set in the parsed fillers
+func checkRules(parsedFillers []*Filler) error {
+	for _, filler := range parsedFillers {
+		for _, info := range filler.flags {
+			if !info.isSet() {
+				continue
+			}
+			for _, req := range info.requires {
+				if !req.isSet() {
+					return fmt.Errorf("%w: -%v requires -%v", ErrMissingRequiredFlag, info.name, req.name)
+				}
+			}
+			for _, con := range info.conflicts {
+				if con.isSet() {
+					return fmt.Errorf("%w: -%v can't be used together with -%v", ErrConflictingFlags, info.name, con.name)
+				}
+			}
+		}
+	}
+	return nil
+}
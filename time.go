@@ -35,6 +35,15 @@ func (tc *timeConvertor) FromStr(s string, tag reflect.StructTag) (any, error) {
 	return time.Parse(layout, strings.TrimSpace(s))
 }
 
+// Format implements FormatDescriber interface
+func (tc *timeConvertor) Format(tag reflect.StructTag) string {
+	layout, _ := tag.Lookup("layout")
+	if layout == "" {
+		layout = DefaultTimeLayout
+	}
+	return layout
+}
+
 type durationType time.Duration
 
 func (d *durationType) ToStr(in any, tag reflect.StructTag) string {
@@ -43,3 +52,21 @@ func (d *durationType) ToStr(in any, tag reflect.StructTag) string {
 func (d *durationType) FromStr(s string, tag reflect.StructTag) (any, error) {
 	return time.ParseDuration(s)
 }
+
+// Format implements FormatDescriber interface
+func (d *durationType) Format(tag reflect.StructTag) string {
+	return "duration"
+}
+
+// CompleteValue implements ValueCompleter interface, it returns toComplete followed by each unit
+// if toComplete ends with a digit
+func (d *durationType) CompleteValue(toComplete string, tag reflect.StructTag) []string {
+	if toComplete == "" || toComplete[len(toComplete)-1] < '0' || toComplete[len(toComplete)-1] > '9' {
+		return nil
+	}
+	r := []string{}
+	for _, unit := range []string{"ns", "us", "ms", "s", "m", "h"} {
+		r = append(r, toComplete+unit)
+	}
+	return r
+}
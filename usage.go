@@ -0,0 +1,224 @@
+package myflags
+
+import (
+	"flag"
+	"reflect"
+	"sort"
+	"text/template"
+)
+
+// UsageFlag is the data of a flag used by the usage template
+type UsageFlag struct {
+	//Name is the flag name
+	Name string
+	//Type is the type of the struct field, e.g. "uint16", "[]*netip.Addr"
+	Type string
+	//Placeholder is the value placeholder derived from the field type and tags, e.g. "<uint16, hex>", "<ip,...>",
+	//it could be overridden by placeholder tag; it is empty for bool flag
+	Placeholder string
+	//Default is the default value of the flag, same as flag.Flag.DefValue
+	Default string
+	//Usage is the usage string of the flag
+	Usage string
+	//Tags is the struct field tag of the field
+	Tags reflect.StructTag
+	//Deprecation is the deprecation note of a deprecated flag or an old name, like "renamed to -skip"
+	Deprecation string
+	//Group is the help section of the flag, "" means the default section
+	Group string
+}
+
+// UsageGroup is a help section of flags used by the usage template
+type UsageGroup struct {
+	//Name is the section name specified by group tag, "" for the default section
+	Name  string
+	Flags []UsageFlag
+	//Table is Flags formatted in aligned name, type and description columns with the indent,
+	//wrapped to the usage width
+	Table string
+}
+
+// UsageData is the data of a Filler used by the usage template
+type UsageData struct {
+	//Name is the name of the filler, e.g. the action name
+	Name string
+	//Aliases are the alternative names of the action, from aliases tag
+	Aliases []string
+	//IsDefault is true if the action is the default action of its level, from default tag
+	IsDefault bool
+	//Description is the usage string of the filler
+	Description string
+	//LongDescription is the long description of the filler, from Describer interface or description tag
+	LongDescription string
+	//Examples are the examples of the filler, from Exampler interface or examples tag
+	Examples []UsageExample
+	//Indent is the indent of the flags and child actions of the filler, each level adds two spaces
+	Indent string
+	//Width is the width for wrapping, see WithUsageWidth
+	Width int
+	//Flags are the flags of the filler, not including deprecated flags and old names
+	Flags []UsageFlag
+	//Groups are Flags divided into help sections, the default section is the first,
+	//followed by other sections in the order of their first appearance in the struct
+	Groups []UsageGroup
+	//Deprecated are the deprecated flags and old names, only populated with WithShowDeprecated(true)
+	Deprecated []UsageFlag
+	//Actions are the child actions of the filler, in declaration order
+	Actions []*UsageData
+	//Command is the root name followed by the action path of the filler, e.g. "cptool compress",
+	//only populated for the help template
+	Command string
+	//Parents are the ancestors of the filler from the root, each only has its flags,
+	//only populated for the help template
+	Parents []*UsageData
+}
+
+// FlagOrder specifies the order of flags in usage and generated output
+type FlagOrder int
+
+const (
+	//FlagOrderDeclaration orders flags by the declaration order of struct fields
+	FlagOrderDeclaration FlagOrder = iota
+	//FlagOrderAlphabetical orders flags by name
+	FlagOrderAlphabetical
+)
+
+// WithFlagOrder returns a FillerOption that specifies the order of flags, default is FlagOrderDeclaration
+func WithFlagOrder(o FlagOrder) FillerOption {
+	return func(filler *Filler) {
+		filler.flagOrder = o
+	}
+}
+
+// DefaultUsageTemplate is the default template used by Filler.UsageStr, it could be cloned and customized
+var DefaultUsageTemplate = template.Must(template.New("usage").Parse(
+	`{{.Description}}
+{{range .Groups}}{{if .Name}}{{$.Indent}}{{.Name}}:
+{{end}}{{.Table}}{{end}}{{if .Deprecated}}{{.Indent}}deprecated:
+{{range .Deprecated}}{{$.Indent}}- {{.Name}}: {{.Deprecation}}
+{{end}}{{end}}{{range .Actions}}{{$.Indent}}= {{.Name}}{{if .Aliases}} ({{range $i, $a := .Aliases}}{{if $i}}, {{end}}{{$a}}{{end}}){{end}}{{if .IsDefault}} [default]{{end}}: {{template "usage" .}}{{end}}`))
+
+// WithUsageTemplate returns a FillerOption that specifies the template used by Filler.UsageStr,
+// the template is executed with a *UsageData of the filler, and could invoke itself
+// by its name for child actions; default is DefaultUsageTemplate
+func WithUsageTemplate(t *template.Template) FillerOption {
+	return func(filler *Filler) {
+		filler.usageTemplate = t
+	}
+}
+
+// typeStr returns the type of the field
+func (info *flagInfo) typeStr() string {
+	return info.ref.Type().Elem().String()
+}
+
+// newUsageFlag returns the UsageFlag of f
+func (filler *Filler) newUsageFlag(f *flag.Flag) UsageFlag {
+	r := UsageFlag{
+		Name:        f.Name,
+		Default:     f.DefValue,
+		Usage:       f.Usage,
+		Deprecation: filler.deprecationNote(f.Name),
+	}
+	info, ok := filler.flagMap[f.Name]
+	if !ok {
+		info = filler.oldNameMap[f.Name]
+	}
+	if info != nil {
+		r.Type = info.typeStr()
+		r.Placeholder = info.placeholder()
+		r.Tags = info.tag
+		r.Group = info.group
+	}
+	return r
+}
+
+// usageData returns the UsageData of filler and its descendants, indent is the indent of filler's flags
+func (filler *Filler) usageData(indent string) *UsageData {
+	r := &UsageData{
+		Name:            filler.fs.Name(),
+		Aliases:         filler.aliases,
+		IsDefault:       filler.parent != nil && filler.parent.defaultAction == filler.fs.Name(),
+		Description:     filler.usage,
+		LongDescription: filler.longDescription(),
+		Examples:        filler.usageExamples(),
+		Indent:          indent,
+		Width:           filler.getUsageWidth(),
+		Flags:           []UsageFlag{},
+		Deprecated:      []UsageFlag{},
+		Actions:         []*UsageData{},
+	}
+	for _, info := range filler.visibleFlags() {
+		f := filler.fs.Lookup(info.name)
+		if info.isDeprecated {
+			if filler.showDeprecated {
+				r.Deprecated = append(r.Deprecated, filler.newUsageFlag(f))
+			}
+		} else {
+			r.Flags = append(r.Flags, filler.newUsageFlag(f))
+		}
+		if filler.showDeprecated {
+			for _, old := range info.oldNames {
+				r.Deprecated = append(r.Deprecated, filler.newUsageFlag(filler.fs.Lookup(old)))
+			}
+		}
+	}
+	for _, f := range filler.undeclaredFlags() {
+		r.Flags = append(r.Flags, filler.newUsageFlag(f))
+	}
+	r.Groups = filler.groupFlags(r.Flags)
+	setTables(r, r.Width)
+	for _, childname := range filler.visibleActions() {
+		r.Actions = append(r.Actions, filler.fsMap[childname].usageData(indent+"  "))
+	}
+	return r
+}
+
+// orderedFlags returns flags of filler in the order specified by WithFlagOrder
+func (filler *Filler) orderedFlags() []*flagInfo {
+	r := make([]*flagInfo, len(filler.flags))
+	copy(r, filler.flags)
+	if filler.flagOrder == FlagOrderAlphabetical {
+		sort.SliceStable(r, func(i, j int) bool {
+			return r[i].name < r[j].name
+		})
+	}
+	return r
+}
+
+// undeclaredFlags returns flags added to filler's flagset directly, e.g. via GetFlagset, sorted by name
+func (filler *Filler) undeclaredFlags() []*flag.Flag {
+	r := []*flag.Flag{}
+	filler.fs.VisitAll(func(f *flag.Flag) {
+		_, declared := filler.flagMap[f.Name]
+		_, isOld := filler.oldNameMap[f.Name]
+		if !declared && !isOld {
+			r = append(r, f)
+		}
+	})
+	return r
+}
+
+// groupFlags divides flags into help sections, the default section is the first,
+// followed by other sections in the order of their first appearance in filler.flags
+func (filler *Filler) groupFlags(flags []UsageFlag) []UsageGroup {
+	r := []UsageGroup{{Name: ""}}
+	index := map[string]int{"": 0}
+	for _, info := range filler.flags {
+		if _, ok := index[info.group]; !ok {
+			index[info.group] = len(r)
+			r = append(r, UsageGroup{Name: info.group})
+		}
+	}
+	for _, f := range flags {
+		r[index[f.Group]].Flags = append(r[index[f.Group]].Flags, f)
+	}
+	//remove empty sections
+	groups := []UsageGroup{}
+	for _, g := range r {
+		if len(g.Flags) > 0 {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
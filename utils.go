@@ -3,8 +3,18 @@ package myflags
 import (
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 )
 
+// parseBoolTag parses the value of a bool struct field tag, an empty value means true
+func parseBoolTag(v string) (bool, error) {
+	if strings.TrimSpace(v) == "" {
+		return true, nil
+	}
+	return strconv.ParseBool(strings.TrimSpace(v))
+}
+
 // PrettyStruct returns a pretty formatted string representation of in
 func PrettyStruct(in any, prefix string) string {
 	inT := reflect.TypeOf(in)
@@ -0,0 +1,86 @@
+package myflags
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Validator could be implemented by the root struct, a nested struct or an action struct,
+// its Validate method is called by ParseArgs after the level of the struct is parsed,
+// only structs on the parsed action path are validated.
+type Validator interface {
+	Validate() error
+}
+
+var validatorInt = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// ValidateOrder specifies the order of calling Validate methods
+type ValidateOrder int
+
+const (
+	//ValidateDeepestFirst calls Validate of the deepest parsed action first, and the root struct last;
+	//within a level, nested struct is validated before its containing struct
+	ValidateDeepestFirst ValidateOrder = iota
+	//ValidateRootFirst is the reverse order of ValidateDeepestFirst
+	ValidateRootFirst
+)
+
+// WithValidateOrder returns a FillerOption that specifies the order of calling Validate methods,
+// default is ValidateDeepestFirst
+func WithValidateOrder(o ValidateOrder) FillerOption {
+	return func(filler *Filler) {
+		filler.validateOrder = o
+	}
+}
+
+// ValidationError is returned by ParseArgs when a Validate method returns an error
+type ValidationError struct {
+	//Actions is the parsed action path of the failed struct, empty for the root level
+	Actions []string
+	Err     error
+}
+
+func (ve *ValidationError) Error() string {
+	if len(ve.Actions) == 0 {
+		return fmt.Sprintf("validation failed, %v", ve.Err)
+	}
+	return fmt.Sprintf("validation failed for action %v, %v", strings.Join(ve.Actions, " "), ve.Err)
+}
+
+func (ve *ValidationError) Unwrap() error {
+	return ve.Err
+}
+
+// addValidator records inV if it implements Validator, inV must be a pointer to struct
+func (filler *Filler) addValidator(inV reflect.Value) {
+	if inV.Type().Implements(validatorInt) {
+		filler.validators = append(filler.validators, inV)
+	}
+}
+
+// runValidators calls Validate methods of the parsed fillers,
+// parsedActions are the action names returned by parseArgs
+func (filler *Filler) runValidators(parsedActions []string, parsedFillers []*Filler) error {
+	type validateCall struct {
+		actions []string
+		val     reflect.Value
+	}
+	calls := []validateCall{}
+	for i := len(parsedFillers) - 1; i >= 0; i-- {
+		for _, v := range parsedFillers[i].validators {
+			calls = append(calls, validateCall{actions: parsedActions[:i], val: v})
+		}
+	}
+	if filler.validateOrder == ValidateRootFirst {
+		for i, j := 0, len(calls)-1; i < j; i, j = i+1, j-1 {
+			calls[i], calls[j] = calls[j], calls[i]
+		}
+	}
+	for _, c := range calls {
+		if err := c.val.Interface().(Validator).Validate(); err != nil {
+			return &ValidationError{Actions: c.actions, Err: err}
+		}
+	}
+	return nil
+}
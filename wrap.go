@@ -0,0 +1,129 @@
+package myflags
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	//DefaultUsageWidth is the width of usage and help when neither WithUsageWidth nor $COLUMNS is specified
+	DefaultUsageWidth = 80
+	//MinUsageWidth is the minimal width of usage and help
+	MinUsageWidth = 40
+	//minDescWidth is the minimal width of the description column
+	minDescWidth = 20
+)
+
+// WithUsageWidth returns a FillerOption that specifies the width for wrapping usage and help,
+// by default the width is from environment variable COLUMNS, or DefaultUsageWidth if it is not set;
+// the width is at least MinUsageWidth
+func WithUsageWidth(width int) FillerOption {
+	return func(filler *Filler) {
+		filler.usageWidth = width
+	}
+}
+
+// getUsageWidth returns the width for wrapping usage and help
+func (filler *Filler) getUsageWidth() int {
+	w := filler.usageWidth
+	if w <= 0 {
+		if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("COLUMNS"))); err == nil {
+			w = n
+		}
+	}
+	if w <= 0 {
+		w = DefaultUsageWidth
+	}
+	if w < MinUsageWidth {
+		w = MinUsageWidth
+	}
+	return w
+}
+
+// wrapText wraps text into lines with the specified width, explicit newlines in text are preserved,
+// a word longer than width is put in its own line
+func wrapText(text string, width int) []string {
+	r := []string{}
+	for _, para := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+				line += " " + word
+			default:
+				r = append(r, line)
+				line = word
+			}
+		}
+		r = append(r, line)
+	}
+	return r
+}
+
+// wrapIndented wraps text with the width minus the indent, and adds indent to each line
+func wrapIndented(text, indent string, width int) string {
+	w := width - utf8.RuneCountInString(indent)
+	if w < minDescWidth {
+		w = minDescWidth
+	}
+	lines := wrapText(text, w)
+	for i := range lines {
+		lines[i] = strings.TrimRight(indent+lines[i], " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func padRight(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// flagTable formats flags in aligned name, placeholder and description columns with indent,
+// the description includes usage and default value, wrapped to the width;
+// nameW and valueW are the widths of name and placeholder column
+func flagTable(flags []UsageFlag, indent string, nameW, valueW, width int) string {
+	buf := new(strings.Builder)
+	descIndent := indent + strings.Repeat(" ", 2+nameW+2+valueW+2)
+	descW := width - utf8.RuneCountInString(descIndent)
+	if descW < minDescWidth {
+		descW = minDescWidth
+	}
+	for _, f := range flags {
+		desc := f.Usage
+		if f.Default != "" {
+			if desc != "" {
+				desc += "\n"
+			}
+			desc += "default: " + f.Default
+		}
+		lines := wrapText(desc, descW)
+		first := indent + "- " + padRight(f.Name, nameW) + "  " + padRight(f.Placeholder, valueW) + "  " + lines[0]
+		buf.WriteString(strings.TrimRight(first, " ") + "\n")
+		for _, l := range lines[1:] {
+			buf.WriteString(strings.TrimRight(descIndent+l, " ") + "\n")
+		}
+	}
+	return buf.String()
+}
+
+// setTables sets Table of each group in data, columns are aligned across all groups
+func setTables(data *UsageData, width int) {
+	nameW, valueW := 0, 0
+	for _, f := range data.Flags {
+		if n := utf8.RuneCountInString(f.Name); n > nameW {
+			nameW = n
+		}
+		if n := utf8.RuneCountInString(f.Placeholder); n > valueW {
+			valueW = n
+		}
+	}
+	for i := range data.Groups {
+		data.Groups[i].Table = flagTable(data.Groups[i].Flags, data.Indent, nameW, valueW, width)
+	}
+}
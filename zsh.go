@@ -0,0 +1,151 @@
+package myflags
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// zshEscape escapes s for the description or message of a zsh _arguments spec in single quotes
+func zshEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, `:`, `\:`).Replace(s)
+	return strings.ReplaceAll(s, "'", `'\''`)
+}
+
+// zshConflicts returns names of flags in the same level that can't be used together with info
+func zshConflicts(level *completionLevel, info *flagInfo) []string {
+	r := []string{}
+	for _, other := range level.flags {
+		if other != info && (containsFlag(info.conflicts, other) || containsFlag(other.conflicts, info)) {
+			r = append(r, "-"+other.name)
+		}
+	}
+	return r
+}
+
+func containsFlag(flags []*flagInfo, info *flagInfo) bool {
+	for _, f := range flags {
+		if f == info {
+			return true
+		}
+	}
+	return false
+}
+
+// zshFlagSpec returns the _arguments spec of info, the value is completed by function valueFunc
+func zshFlagSpec(level *completionLevel, info *flagInfo, valueFunc string) string {
+	spec := ""
+	if cons := zshConflicts(level, info); len(cons) > 0 {
+		spec = "(" + strings.Join(cons, " ") + ")"
+	}
+	spec += "-" + info.name
+	desc := ""
+	if usage := info.usageLine(); usage != "" {
+		desc = "[" + zshEscape(usage) + "]"
+	}
+	if info.isBool() {
+		return spec + desc
+	}
+	return spec + "=" + desc + ":" + zshEscape(info.placeholder()) + ":" + valueFunc
+}
+
+// zshValueTemplate is the function completing flag values, %[1]v is the function name prefix,
+// %[2]v is CompleteActionName
+const zshValueTemplate = `%[1]v_value() {
+    local -a candidates dirs
+    candidates=("${(@f)$("${%[1]v_words[1]}" %[2]v "${(@)%[1]v_words[2,-1]}" "$IPREFIX$PREFIX" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    if [[ -n $IPREFIX && $PREFIX != *=* ]]; then
+        candidates=("${(@)candidates#*=}")
+    fi
+    dirs=(${(M)candidates:#*/})
+    candidates=(${candidates:#*/})
+    compadd -a candidates
+    compadd -S '' -a dirs
+}
+
+`
+
+// zshFuncName returns the zsh function name of the level
+func zshFuncName(prog string, level *completionLevel) string {
+	return "_" + completionFuncName(strings.Join(append([]string{prog}, level.path...), "_"))
+}
+
+// GenZshCompletion writes a zsh completion function "_<prog>" of the filler to w, it completes flags
+// with their usage as descriptions, action names with their usage, excludes flags conflicting with
+// the ones already specified; flag values are completed by calling the command with CompleteActionName;
+// hidden and deprecated flags and hidden actions are not completed.
+// The output could be saved as file "_<prog>" in a directory of $fpath.
+func (filler *Filler) GenZshCompletion(w io.Writer) error {
+	name := filler.fs.Name()
+	funcName := "_" + completionFuncName(name)
+	levels := filler.completionLevels()
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "#compdef %v\n# zsh completion for %v, generated by myflags\n\n", name, name)
+	// action lists of each level, the level is specified by the action path in $1
+	fmt.Fprintf(buf, "%v_actions() {\n    local -a actions\n    case \"$1\" in\n", funcName)
+	for _, level := range levels {
+		if len(level.actions) == 0 && !(len(level.path) == 0 && filler.hasHelpAction()) {
+			continue
+		}
+		fmt.Fprintf(buf, "    %v)\n        actions=(\n", shQuote(strings.Join(level.path, " ")))
+		for _, act := range level.actions {
+			item := strings.ReplaceAll(act, ":", `\:`)
+			if usage := level.actionUsageLine(act); usage != "" {
+				item += ":" + usage
+			}
+			fmt.Fprintf(buf, "            %v\n", shQuote(item))
+		}
+		if len(level.path) == 0 && filler.hasHelpAction() {
+			fmt.Fprintf(buf, "            %v\n", shQuote(HelpActionName+":show help of an action"))
+		}
+		fmt.Fprintln(buf, "        )\n        ;;")
+	}
+	fmt.Fprintf(buf, "    esac\n    _describe -t actions 'action' actions\n}\n\n")
+	// flag values are completed by the command, with the words saved by the root level function
+	fmt.Fprintf(buf, zshValueTemplate, funcName, CompleteActionName)
+	if filler.hasHelpAction() {
+		fmt.Fprintf(buf, "%v_%v() {\n", funcName, completionFuncName(HelpActionName))
+		fmt.Fprintf(buf, "    %v_actions \"${(j: :)words[2,CURRENT-1]}\"\n}\n\n", funcName)
+	}
+	for _, level := range levels {
+		fmt.Fprintf(buf, "%v() {\n", zshFuncName(name, level))
+		fmt.Fprintln(buf, "    local curcontext=\"$curcontext\" state line ret=1")
+		fmt.Fprintln(buf, "    typeset -A opt_args")
+		if len(level.path) == 0 {
+			fmt.Fprintf(buf, "    local -a %v_words\n    %v_words=(\"${(@)words[1,CURRENT-1]}\")\n", funcName, funcName)
+		}
+		fmt.Fprint(buf, "    _arguments -C")
+		for _, info := range level.flags {
+			fmt.Fprintf(buf, " \\\n        '%v'", zshFlagSpec(level, info, funcName+"_value"))
+		}
+		hasActions := len(level.actions) > 0 || (len(level.path) == 0 && filler.hasHelpAction())
+		if hasActions {
+			fmt.Fprint(buf, " \\\n        '1: :->action' \\\n        '*:: :->args'")
+		}
+		fmt.Fprintln(buf, " && ret=0")
+		if hasActions {
+			fmt.Fprintln(buf, "    case $state in\n    action)")
+			fmt.Fprintf(buf, "        %v_actions %v && ret=0\n        ;;\n", funcName, shQuote(strings.Join(level.path, " ")))
+			fmt.Fprintln(buf, "    args)\n        case $line[1] in")
+			for _, act := range level.actions {
+				child := &completionLevel{path: append(append([]string{}, level.path...), act)}
+				patterns := []string{shQuote(act)}
+				for _, alias := range level.actionAliases(act) {
+					patterns = append(patterns, shQuote(alias))
+				}
+				fmt.Fprintf(buf, "        %v)\n            %v && ret=0\n            ;;\n", strings.Join(patterns, "|"), zshFuncName(name, child))
+			}
+			if len(level.path) == 0 && filler.hasHelpAction() {
+				fmt.Fprintf(buf, "        %v)\n            %v_%v && ret=0\n            ;;\n", shQuote(HelpActionName),
+					funcName, completionFuncName(HelpActionName))
+			}
+			fmt.Fprintln(buf, "        esac\n        ;;\n    esac")
+		}
+		fmt.Fprint(buf, "    return ret\n}\n\n")
+	}
+	fmt.Fprintf(buf, "if [[ $zsh_eval_context[-1] == loadautofunc ]]; then\n    %v \"$@\"\nelse\n    compdef %v %v\nfi\n", funcName, funcName, shQuote(name))
+	_, err := w.Write(buf.Bytes())
+	return err
+}